@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+)
+
+func runExport(e *env, args []string) error {
+	fs := e.flagSet("export")
+	output := fs.String("o", "-", "`FILE` to write the archive to, - for stdout")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	w := e.stdout
+	var f *os.File
+	if *output != "-" {
+		f, err = os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		w = f
+	}
+
+	count, err := c.Export(e.table, w)
+	if f != nil {
+		// Close reports writes that did not make it to disk
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			// A partial archive is useless and would make a retry to the same path fail
+			os.Remove(*output)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "Exported %d items from %s\n", count, e.table)
+	return nil
+}
+
+func runImport(e *env, args []string) error {
+	fs := e.flagSet("import")
+	conflict := fs.String("conflict", string(credstash.ConflictFail), "what to do with items that already exist: skip, overwrite or fail (`MODE`)")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = e.stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	result, err := c.Import(e.table, r, credstash.ConflictMode(*conflict))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Imported %d items into %s, overwrote %d and skipped %d\n",
+		result.Imported, e.table, result.Overwritten, result.Skipped)
+	return nil
+}
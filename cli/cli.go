@@ -0,0 +1,226 @@
+// Package cli implements a command line interface compatible with the Python credstash CLI
+// on top of the credstash package, so the provider binary can stand in for it.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+)
+
+const (
+	defaultRegion = "us-east-1"
+	defaultTable  = "credential-store"
+)
+
+type command struct {
+	usage string
+	help  string
+	run   func(e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"delete":    {"delete [-soft [-window DURATION]] credential", "Delete a credential from the store", runDelete},
+	"export":    {"export [-o FILE]", "Export every item, still encrypted, to a backup archive", runExport},
+	"get":       {"get [-n] [-v VERSION] [-f FORMAT] credential [context ...]", "Get a credential from the store", runGet},
+	"getall":    {"getall [-v VERSION] [-f FORMAT] [context ...]", "Get all credentials from the store", runGetAll},
+	"import":    {"import [-conflict MODE] file", "Restore a backup archive written by export", runImport},
+	"keys":      {"keys", "List all keys in the store", runKeys},
+	"list":      {"list", "List credentials and their versions", runList},
+	"purge":     {"purge [-window DURATION]", "Remove soft deleted credentials whose restore window has passed", runPurge},
+	"put":       {"put [-k KEY] [-c COMMENT] [-v VERSION | -a] [-d DIGEST] credential value [context ...]", "Put a credential into the store", runPut},
+	"reencrypt": {"reencrypt [-k KEY] [-all-versions] [-state FILE] [-context KEY=VALUE ...] [-new-context KEY=VALUE ...] [credential ...]", "Re-encrypt credentials under a new KMS key or encryption context", runReencrypt},
+	"restore":   {"restore credential", "Restore a soft deleted credential", runRestore},
+	"setup":     {"setup [--tags KEY=VALUE ...] [-ttl]", "Setup the credential store", runSetup},
+}
+
+// errUsage is returned by commands after they printed their usage
+var errUsage = errors.New("invalid usage")
+
+// env holds the global options and output streams shared by all commands
+type env struct {
+	region  string
+	table   string
+	profile string
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// cmd is the command being run, used to print its usage
+	cmd    command
+	client *credstash.Client
+	// opts are set by commands before they create the client
+	opts credstash.Options
+}
+
+// Run executes a credstash command line and returns the process exit code
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	e := &env{table: defaultTable, stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("credstash", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	e.addGlobalFlags(fs)
+	fs.Usage = func() { e.usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		e.usage(fs)
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "credstash: unknown command %q\n", fs.Arg(0))
+		e.usage(fs)
+		return 2
+	}
+
+	e.cmd = cmd
+	err := cmd.run(e, fs.Args()[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	}
+	fmt.Fprintf(stderr, "%s\n", err)
+	return 1
+}
+
+func (e *env) addGlobalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&e.region, "r", e.region, "the AWS `REGION` in which to operate. If a region is not specified, credstash will use "+
+		"the value of the AWS_DEFAULT_REGION env variable, or if that is not set, the value in ~/.aws/config. "+
+		"As a last resort, it will use "+defaultRegion)
+	fs.StringVar(&e.table, "t", e.table, "DynamoDB `TABLE` to use for credential storage")
+	fs.StringVar(&e.profile, "p", e.profile, "Boto config `PROFILE` to use when connecting to AWS")
+}
+
+func (e *env) usage(fs *flag.FlagSet) {
+	fmt.Fprintf(e.stderr, "usage: credstash [-r REGION] [-t TABLE] [-p PROFILE] {%s} ...\n\n", strings.Join(commandNames(), ","))
+	fmt.Fprintln(e.stderr, "A credential/secret storage system")
+	fmt.Fprintln(e.stderr, "\ncommands:")
+	for _, name := range commandNames() {
+		fmt.Fprintf(e.stderr, "  %-9s %s\n", name, commands[name].help)
+	}
+	fmt.Fprintln(e.stderr, "\noptions:")
+	fs.PrintDefaults()
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flagSet creates the flag set of a command. The global flags are accepted after the
+// command name too, like the Python CLI.
+func (e *env) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	e.addGlobalFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: credstash %s\n\n%s\n\noptions:\n", e.cmd.usage, e.cmd.help)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the command line of a command and checks the number of positional arguments.
+// Flags may follow positional arguments like with the Python CLI, so `put name value -k KEY`
+// does not take `-k KEY` for encryption context. Arguments after `--` are always positional.
+func parse(fs *flag.FlagSet, args []string, minArgs int, maxArgs int) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return err
+			}
+			return errUsage
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	// Leave only the positional arguments in fs.Args
+	if err := fs.Parse(append([]string{"--"}, positional...)); err != nil {
+		return errUsage
+	}
+
+	if fs.NArg() < minArgs || (maxArgs >= 0 && fs.NArg() > maxArgs) {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// credstash lazily creates the client once the global flags are known
+func (e *env) credstash() (*credstash.Client, error) {
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	opts := session.Options{
+		Profile:           e.profile,
+		SharedConfigState: session.SharedConfigEnable,
+	}
+	if e.region != "" {
+		opts.Config.Region = aws.String(e.region)
+	}
+	sess, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String(defaultRegion)
+	}
+
+	e.client = credstash.NewWithOptions(e.table, sess, e.opts)
+	return e.client, nil
+}
+
+// parseContext converts `key=value` arguments into an encryption context
+func parseContext(args []string) (*credstash.EncryptionContextValue, error) {
+	context := credstash.NewEncryptionContextValue()
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%s is not a properly formatted key=value pair", arg)
+		}
+		value := parts[1]
+		(*context)[parts[0]] = &value
+	}
+	return context, nil
+}
+
+// paddedVersion pads numeric versions like the Python CLI does, other versions are used as is
+func paddedVersion(c *credstash.Client, version string) string {
+	if n, err := strconv.Atoi(version); err == nil && n >= 0 {
+		return c.PaddedInt(n)
+	}
+	return version
+}
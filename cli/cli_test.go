@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContext(t *testing.T) {
+	context, err := parseContext([]string{"env=prod", "app=a=b"})
+	assert.Nil(t, err)
+	assert.Equal(t, "prod", aws.StringValue((*context)["env"]))
+	assert.Equal(t, "a=b", aws.StringValue((*context)["app"]))
+
+	_, err = parseContext([]string{"env"})
+	assert.Error(t, err)
+}
+
+func TestTagsFlag(t *testing.T) {
+	tags := tagsFlag{}
+	assert.Nil(t, tags.Set("team=platform env=prod"))
+	assert.Nil(t, tags.Set("owner=ops"))
+	assert.Equal(t, tagsFlag{"team": "platform", "env": "prod", "owner": "ops"}, tags)
+	assert.Error(t, tags.Set("broken"))
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"unknown"},
+		{"get"},
+		{"put", "name"},
+		{"put", "-a", "-v", "2", "name", "value"},
+		{"delete", "a", "b"},
+		{"restore"},
+		{"purge", "name"},
+	}
+	for _, args := range cases {
+		var stdout, stderr bytes.Buffer
+		code := Run(args, nil, &stdout, &stderr)
+		assert.Equal(t, 2, code, "args %v", args)
+		assert.Contains(t, stderr.String(), "usage: credstash", "args %v", args)
+	}
+}
+
+func TestParseInterspersedFlags(t *testing.T) {
+	cases := []struct {
+		args       []string
+		key        string
+		positional []string
+	}{
+		{args: []string{"-k", "alias/foo", "name", "value"}, key: "alias/foo", positional: []string{"name", "value"}},
+		{args: []string{"name", "value", "-k", "alias/foo", "env=prod"}, key: "alias/foo", positional: []string{"name", "value", "env=prod"}},
+		{args: []string{"name", "--", "-k", "alias/foo"}, key: "", positional: []string{"name", "-k", "alias/foo"}},
+		{args: []string{"name", "-"}, key: "", positional: []string{"name", "-"}},
+	}
+	for _, tc := range cases {
+		fs := flag.NewFlagSet("put", flag.ContinueOnError)
+		key := fs.String("k", "", "")
+
+		assert.Nil(t, parse(fs, tc.args, 2, -1), "args %v", tc.args)
+		assert.Equal(t, tc.key, *key, "args %v", tc.args)
+		assert.Equal(t, tc.positional, fs.Args(), "args %v", tc.args)
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, Run([]string{"-h"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "-t TABLE")
+}
+
+func TestReadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	done, upTo, err := readState(path)
+	assert.Nil(t, err)
+	assert.Empty(t, done)
+	assert.Empty(t, upTo)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("alpha\n\nbeta\tupto\t0000000000000000003\nbeta\t0000000000000000001\n"), 0600))
+	done, upTo, err = readState(path)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"alpha": true, stateKey("beta", "0000000000000000001"): true}, done)
+	assert.Equal(t, map[string]string{"beta": "0000000000000000003"}, upTo)
+}
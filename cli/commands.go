@@ -0,0 +1,383 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/granular-oss/terraform-provider-credstash/render"
+)
+
+func runGet(e *env, args []string) error {
+	fs := e.flagSet("get")
+	noNewline := fs.Bool("n", false, "Don't append newline to returned value (useful in scripts or with binary files)")
+	version := fs.String("v", "", "Get a specific `VERSION` of the credential (defaults to the latest version)")
+	format := fs.String("f", "json", "Output `FORMAT` for wildcard gets: "+strings.Join(render.Formats, ", "))
+	if err := parse(fs, args, 1, -1); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	context, err := parseContext(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	if strings.ContainsAny(name, "*?[") {
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("invalid wildcard %q: %w", name, err)
+		}
+		secrets, err := getAll(e, c, *version, context, func(n string) bool {
+			matched, _ := path.Match(name, n)
+			return matched
+		})
+		if err != nil {
+			return err
+		}
+		out, err := render.Render(secrets, *format)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(e.stdout, out)
+		return nil
+	}
+
+	var secret *credstash.DecryptedCredential
+	if *version == "" {
+		secret, err = c.GetHighestVersionSecret(e.table, name, context)
+	} else {
+		secret, err = c.GetSecret(name, e.table, paddedVersion(c, *version), context)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(e.stdout, secret.Secret)
+	if !*noNewline {
+		fmt.Fprintln(e.stdout)
+	}
+	return nil
+}
+
+func runGetAll(e *env, args []string) error {
+	fs := e.flagSet("getall")
+	version := fs.String("v", "", "Get a specific `VERSION` of the credential (defaults to the latest version)")
+	format := fs.String("f", "json", "Output `FORMAT`: "+strings.Join(render.Formats, ", "))
+	if err := parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+
+	context, err := parseContext(fs.Args())
+	if err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	var secrets map[string]string
+	if *version == "" {
+		secrets, err = c.GetAllSecrets(e.table, "", context)
+	} else {
+		secrets, err = getAll(e, c, *version, context, func(string) bool { return true })
+	}
+	if err != nil {
+		return err
+	}
+	out, err := render.Render(secrets, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(e.stdout, out)
+	return nil
+}
+
+// getAll decrypts the given version, or the latest one, of every secret whose name matches
+func getAll(e *env, c *credstash.Client, version string, context *credstash.EncryptionContextValue, match func(string) bool) (map[string]string, error) {
+	creds, err := c.ListSecrets(e.table)
+	if err != nil {
+		return nil, err
+	}
+
+	// The listing is sorted by name and version, so the last entry per name is the latest
+	selected := map[string]string{}
+	var names []string
+	for _, cred := range creds {
+		if !match(cred.Name) {
+			continue
+		}
+		if version != "" && cred.Version != paddedVersion(c, version) {
+			continue
+		}
+		if _, seen := selected[cred.Name]; !seen {
+			names = append(names, cred.Name)
+		}
+		selected[cred.Name] = cred.Version
+	}
+
+	refs := make([]credstash.SecretRef, 0, len(names))
+	for _, name := range names {
+		refs = append(refs, credstash.SecretRef{Table: e.table, Name: name, Version: selected[name], Context: context})
+	}
+	decrypted, err := c.GetSecrets(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string, len(decrypted))
+	for _, secret := range decrypted {
+		secrets[secret.Name] = secret.Secret
+	}
+	return secrets, nil
+}
+
+func runPut(e *env, args []string) error {
+	fs := e.flagSet("put")
+	key := fs.String("k", credstash.DefaultKmsKey, "the KMS `KEY` id of the master key to use")
+	comment := fs.String("c", "", "Include reference information or a `COMMENT` about value to be stored")
+	version := fs.String("v", "", "Put a specific `VERSION` of the credential (update the credential; defaults to version 1)")
+	autoversion := fs.Bool("a", false, "Automatically increment the version of the credential to be stored")
+	digest := fs.String("d", credstash.DefaultDigest, "the hashing algorithm (`DIGEST`) used to calculate the HMAC")
+	if err := parse(fs, args, 2, -1); err != nil {
+		return err
+	}
+	if *autoversion && *version != "" {
+		fmt.Fprintln(e.stderr, "credstash put: -a and -v cannot be used together")
+		fs.Usage()
+		return errUsage
+	}
+	if *digest != credstash.DefaultDigest {
+		return fmt.Errorf("unsupported digest %q, only %s is supported", *digest, credstash.DefaultDigest)
+	}
+
+	name := fs.Arg(0)
+	value, err := readValue(e, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	context, err := parseContext(fs.Args()[2:])
+	if err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	padded := c.PaddedInt(1)
+	if *autoversion {
+		padded, err = c.ResolveVersion(e.table, name, 0)
+		if err != nil {
+			return err
+		}
+	} else if *version != "" {
+		padded = paddedVersion(c, *version)
+	}
+
+	err = c.PutSecretWithOptions(e.table, name, value, padded, context, credstash.PutOptions{
+		KmsKey:  *key,
+		Comment: *comment,
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return fmt.Errorf("%s version %s is already in the credential store. Use the -v flag to specify a new version", name, padded)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "%s has been stored\n", name)
+	return nil
+}
+
+// readValue reads the value from stdin for `-` and from a file for `@path`
+func readValue(e *env, arg string) (string, error) {
+	switch {
+	case arg == "-":
+		b, err := ioutil.ReadAll(e.stdin)
+		return string(b), err
+	case strings.HasPrefix(arg, "@"):
+		b, err := ioutil.ReadFile(arg[1:])
+		return string(b), err
+	}
+	return arg, nil
+}
+
+func runList(e *env, args []string) error {
+	fs := e.flagSet("list")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	creds, err := c.ListSecrets(e.table)
+	if err != nil {
+		return err
+	}
+
+	nameWidth, versionWidth := 0, 0
+	for _, cred := range creds {
+		if len(cred.Name) > nameWidth {
+			nameWidth = len(cred.Name)
+		}
+		if len(cred.Version) > versionWidth {
+			versionWidth = len(cred.Version)
+		}
+	}
+	for _, cred := range creds {
+		fmt.Fprintf(e.stdout, "%-*s -- version %-*s -- comment %s\n", nameWidth, cred.Name, versionWidth, cred.Version, cred.Comment)
+	}
+	return nil
+}
+
+func runKeys(e *env, args []string) error {
+	fs := e.flagSet("keys")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	creds, err := c.ListSecrets(e.table)
+	if err != nil {
+		return err
+	}
+
+	last := ""
+	for i, cred := range creds {
+		if i == 0 || cred.Name != last {
+			fmt.Fprintln(e.stdout, cred.Name)
+		}
+		last = cred.Name
+	}
+	return nil
+}
+
+func runDelete(e *env, args []string) error {
+	fs := e.flagSet("delete")
+	soft := fs.Bool("soft", false, "Mark the versions deleted so they can be restored until purged")
+	window := fs.Duration("window", 7*24*time.Hour, "With -soft, the `DURATION` the versions can be restored for before purge removes them")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	e.opts.SoftDelete = *soft
+	e.opts.SoftDeleteWindow = *window
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	versions, err := c.DeleteSecretVersions(e.table, name)
+	for _, version := range versions {
+		fmt.Fprintf(e.stdout, "Deleting %s -- version %s\n", name, version)
+	}
+	return err
+}
+
+func runRestore(e *env, args []string) error {
+	fs := e.flagSet("restore")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	versions, err := c.Restore(e.table, name)
+	for _, version := range versions {
+		fmt.Fprintf(e.stdout, "Restoring %s -- version %s\n", name, version)
+	}
+	return err
+}
+
+func runPurge(e *env, args []string) error {
+	fs := e.flagSet("purge")
+	window := fs.Duration("window", 7*24*time.Hour, "Purge credentials soft deleted without a stored window once `DURATION` has passed")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	e.opts.SoftDeleteWindow = *window
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	purged, err := c.Purge(e.table, time.Now())
+	for _, cred := range purged {
+		fmt.Fprintf(e.stdout, "Purging %s -- version %s\n", cred.Name, cred.Version)
+	}
+	return err
+}
+
+// tagsFlag collects KEY=VALUE tags from repeated or space separated --tags values
+type tagsFlag map[string]string
+
+func (t tagsFlag) String() string {
+	return ""
+}
+
+func (t tagsFlag) Set(value string) error {
+	for _, tag := range strings.Fields(value) {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("%s is not a properly formatted KEY=VALUE tag", tag)
+		}
+		t[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+func runSetup(e *env, args []string) error {
+	fs := e.flagSet("setup")
+	tags := tagsFlag{}
+	fs.Var(tags, "tags", "`TAGS` to apply to the DynamoDB table, as KEY=VALUE pairs")
+	ttl := fs.Bool("ttl", false, "enable DynamoDB TTL so superseded versions can expire")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	exists, err := c.TableExists(e.table)
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Fprintln(e.stdout, "Credential Store table already exists")
+	} else {
+		fmt.Fprintln(e.stdout, "Creating table...")
+		if err := c.CreateTable(e.table, tags); err != nil {
+			return err
+		}
+		fmt.Fprintln(e.stdout, "Table has been created. Go read the README about how to create your KMS key")
+	}
+
+	if *ttl {
+		if err := c.EnableTTL(e.table); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "TTL has been enabled on the %s attribute\n", credstash.TTLAttribute)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+)
+
+// pairsFlag collects repeated key=value flag values
+type pairsFlag []string
+
+func (p *pairsFlag) String() string {
+	return strings.Join(*p, " ")
+}
+
+func (p *pairsFlag) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func runReencrypt(e *env, args []string) error {
+	fs := e.flagSet("reencrypt")
+	key := fs.String("k", credstash.DefaultKmsKey, "the KMS `KEY` id of the new master key")
+	allVersions := fs.Bool("all-versions", false, "Re-encrypt every version instead of only the latest one")
+	state := fs.String("state", "", "`FILE` recording finished credentials and versions, so an interrupted run can be resumed")
+	var fromContext, toContext pairsFlag
+	fs.Var(&fromContext, "context", "current encryption context as a `KEY=VALUE` pair, may be repeated")
+	fs.Var(&toContext, "new-context", "new encryption context as a `KEY=VALUE` pair, may be repeated (defaults to the current context)")
+	if err := parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+
+	context, err := parseContext(fromContext)
+	if err != nil {
+		return err
+	}
+	newContext := context
+	if len(toContext) > 0 {
+		newContext, err = parseContext(toContext)
+		if err != nil {
+			return err
+		}
+	}
+	c, err := e.credstash()
+	if err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		creds, err := c.ListSecrets(e.table)
+		if err != nil {
+			return err
+		}
+		for i, cred := range creds {
+			if i == 0 || cred.Name != creds[i-1].Name {
+				names = append(names, cred.Name)
+			}
+		}
+	}
+
+	done, upTo := map[string]bool{}, map[string]string{}
+	var checkpoint *os.File
+	if *state != "" {
+		if done, upTo, err = readState(*state); err != nil {
+			return err
+		}
+		checkpoint, err = os.OpenFile(*state, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		defer checkpoint.Close()
+	}
+
+	for i, name := range names {
+		if done[name] {
+			fmt.Fprintf(e.stdout, "[%d/%d] %s: already done, skipping\n", i+1, len(names), name)
+			continue
+		}
+
+		// Copies written by an interrupted run are newer than the bound and are not rewritten again
+		bound, ok := upTo[name]
+		if !ok {
+			latest, err := c.GetLatestVersion(e.table, name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			bound = c.PaddedInt(latest)
+			if checkpoint != nil {
+				if _, err := fmt.Fprintln(checkpoint, stateBound(name, bound)); err != nil {
+					return err
+				}
+			}
+		}
+
+		versions, err := c.Reencrypt(e.table, name, context, credstash.ReencryptOptions{
+			KmsKey:      *key,
+			Context:     newContext,
+			AllVersions: *allVersions,
+			UpTo:        bound,
+			Skip: func(version string) bool {
+				return done[stateKey(name, version)]
+			},
+			Done: func(version string) error {
+				if checkpoint == nil {
+					return nil
+				}
+				_, err := fmt.Fprintln(checkpoint, stateKey(name, version))
+				return err
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if len(versions) == 0 {
+			fmt.Fprintf(e.stdout, "[%d/%d] %s: already done, skipping\n", i+1, len(names), name)
+		} else {
+			fmt.Fprintf(e.stdout, "[%d/%d] %s: re-encrypted as version %s\n", i+1, len(names), name, strings.Join(versions, ", "))
+		}
+
+		if checkpoint != nil {
+			if _, err := fmt.Fprintln(checkpoint, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stateKey is the state file line of a finished source version, a line holding only the
+// name marks the whole credential as finished
+func stateKey(name string, version string) string {
+	return name + "\t" + version
+}
+
+// stateBound is the state file line recording the latest version of a credential when the
+// run started on it
+func stateBound(name string, version string) string {
+	return name + "\tupto\t" + version
+}
+
+// readState returns the finished lines of a reencrypt state file and the recorded bound of
+// each started credential, a missing file is empty
+func readState(path string) (map[string]bool, map[string]string, error) {
+	done, upTo := map[string]bool{}, map[string]string{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, upTo, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if fields := strings.Split(line, "\t"); len(fields) == 3 && fields[1] == "upto" {
+			upTo[fields[0]] = fields[2]
+		} else if line != "" {
+			done[line] = true
+		}
+	}
+	return done, upTo, scanner.Err()
+}
@@ -0,0 +1,71 @@
+package credstash
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// LastAccessed returns the time the version was last read with TrackAccess, and false when
+// it never was
+func (c *Credential) LastAccessed() (time.Time, bool) {
+	if c.LastAccessedAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(c.LastAccessedAt, 0), true
+}
+
+// WithoutAccessTracking returns a client sharing the connections of c whose reads are not
+// recorded, for owners reading back their own secrets
+func (c *Client) WithoutAccessTracking() *Client {
+	untracked := *c
+	untracked.opts.TrackAccess = false
+	return &untracked
+}
+
+// recordAccess sets last_accessed_at and increments access_count on a version that was read,
+// when TrackAccess is set, and updates the credential with the stored values. It is best
+// effort: a failure is logged and never fails the read.
+func (c *Client) recordAccess(tableName string, secret *DecryptedCredential, now time.Time) {
+	if !c.opts.TrackAccess {
+		return
+	}
+
+	res, err := c.dynamoDB.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: &tableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			"name":    {S: aws.String(secret.Name)},
+			"version": {S: aws.String(secret.Version)},
+		},
+		ExpressionAttributeNames: map[string]*string{
+			"#N": aws.String("name"),
+			"#L": aws.String("last_accessed_at"),
+			"#C": aws.String("access_count"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+			":one": {N: aws.String("1")},
+		},
+		// Never create an item holding only the counters when the version was just purged
+		ConditionExpression: aws.String("attribute_exists(#N)"),
+		UpdateExpression:    aws.String("SET #L = :now ADD #C :one"),
+		ReturnValues:        aws.String(dynamodb.ReturnValueUpdatedNew),
+	})
+	if err != nil {
+		log.Printf("[WARN] Recording access to %s version %s: %v", secret.Name, secret.Version, err)
+		return
+	}
+
+	// Requests for the same version share the credential, update a copy
+	cred := *secret.Credential
+	cred.LastAccessedAt = now.Unix()
+	if count, ok := res.Attributes["access_count"]; ok {
+		if n, err := strconv.ParseInt(aws.StringValue(count.N), 10, 64); err == nil {
+			cred.AccessCount = n
+		}
+	}
+	secret.Credential = &cred
+}
@@ -0,0 +1,44 @@
+package credstash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackAccess(t *testing.T) {
+	c, db := newFakeClient()
+	c.opts.TrackAccess = true
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.db", "two", 2)
+
+	secret, err := c.GetHighestVersionSecret("", "app.db", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, int64(1), secret.AccessCount)
+	_, accessed := secret.LastAccessed()
+	assert.True(t, accessed)
+
+	secret, err = c.GetSecret("app.db", "", c.PaddedInt(2), NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, int64(2), secret.AccessCount)
+
+	secrets, err := c.GetSecrets([]SecretRef{{Name: "app.db", Version: c.PaddedInt(1)}, {Name: "app.db", Version: c.PaddedInt(1)}})
+	assertNoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2}, []int64{secrets[0].AccessCount, secrets[1].AccessCount})
+
+	assert.Equal(t, "2", *db.Item("credential-store", "app.db", c.PaddedInt(1))["access_count"].N)
+	assert.NotNil(t, db.Item("credential-store", "app.db", c.PaddedInt(1))["last_accessed_at"])
+}
+
+func TestWithoutAccessTracking(t *testing.T) {
+	c, db := newFakeClient()
+	c.opts.TrackAccess = true
+	mustPutSecret(t, c, "app.db", "one", 1)
+
+	secret, err := c.WithoutAccessTracking().GetHighestVersionSecret("", "app.db", NewEncryptionContextValue())
+
+	assertNoError(t, err)
+	assert.Zero(t, secret.AccessCount)
+	assert.Nil(t, db.Item("credential-store", "app.db", c.PaddedInt(1))["access_count"])
+	assert.True(t, c.opts.TrackAccess, "the original client keeps tracking")
+}
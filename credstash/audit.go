@@ -0,0 +1,162 @@
+package credstash
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// AuditProblem classifies an item that failed verification
+type AuditProblem string
+
+const (
+	// AuditCorrupt items decrypt their data key but fail HMAC validation or hold invalid base64
+	AuditCorrupt AuditProblem = "corrupt"
+	// AuditUndecryptable items have a data key KMS refuses to decrypt, usually because of a
+	// different encryption context, a missing permission or a disabled key
+	AuditUndecryptable AuditProblem = "undecryptable"
+	// AuditMalformed items miss attributes, have a version that is not zero padded or an
+	// unsupported digest
+	AuditMalformed AuditProblem = "malformed"
+)
+
+// AuditFinding is one item that failed verification
+type AuditFinding struct {
+	Name    string
+	Version string
+	Problem AuditProblem
+	Err     error
+}
+
+// AuditReport is the result of Verify. Findings are sorted by name and version.
+type AuditReport struct {
+	Items    int
+	Verified int
+	Findings []AuditFinding
+}
+
+// kmsKeyUnusableCodes are KMS errors caused by the key of an item rather than the request
+var kmsKeyUnusableCodes = map[string]bool{
+	kms.ErrCodeNotFoundException:        true,
+	kms.ErrCodeDisabledException:        true,
+	kms.ErrCodeInvalidStateException:    true,
+	kms.ErrCodeIncorrectKeyException:    true,
+	kms.ErrCodeKeyUnavailableException:  true,
+	kms.ErrCodeInvalidKeyUsageException: true,
+}
+
+// Verify scans every version of every secret in the table, decrypts its data key with ctx and
+// validates its HMAC and metadata. Items that fail are reported as findings; only errors
+// unrelated to the items themselves, like a failed scan or throttling, are returned.
+func (c *Client) Verify(tableName string, ctx *EncryptionContextValue) (*AuditReport, error) {
+	tableName = c.TableName(tableName)
+	log.Printf("Verifying table: %s", tableName)
+
+	var items []map[string]*dynamodb.AttributeValue
+	input := &dynamodb.ScanInput{TableName: &tableName, ConsistentRead: aws.Bool(true)}
+	for {
+		res, err := c.dynamoDB.Scan(input)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, res.Items...)
+
+		if len(res.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+
+	findings := make([]*AuditFinding, len(items))
+	err := parallel(len(items), DefaultConcurrency, func(i int) error {
+		finding, err := c.verifyItem(items[i], ctx)
+		findings[i] = finding
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AuditReport{Items: len(items)}
+	for _, finding := range findings {
+		if finding == nil {
+			report.Verified++
+			continue
+		}
+		report.Findings = append(report.Findings, *finding)
+	}
+	sort.Slice(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+	return report, nil
+}
+
+// verifyItem returns a finding when the item fails verification, or nil when it is sound
+func (c *Client) verifyItem(item map[string]*dynamodb.AttributeValue, ctx *EncryptionContextValue) (*AuditFinding, error) {
+	cred := new(Credential)
+	if err := Decode(item, cred); err != nil {
+		finding := &AuditFinding{Problem: AuditMalformed, Err: err}
+		if item["name"] != nil {
+			finding.Name = aws.StringValue(item["name"].S)
+		}
+		if item["version"] != nil {
+			finding.Version = aws.StringValue(item["version"].S)
+		}
+		return finding, nil
+	}
+
+	malformed := func(format string, args ...interface{}) *AuditFinding {
+		return &AuditFinding{Name: cred.Name, Version: cred.Version, Problem: AuditMalformed, Err: fmt.Errorf(format, args...)}
+	}
+	switch {
+	case cred.Name == "":
+		return malformed("item has no name"), nil
+	case !isPaddedVersion(cred.Version):
+		return malformed("version %q is not a %d digit zero padded number", cred.Version, MaxPaddingLength), nil
+	case cred.Key == "" || cred.Contents == "" || len(cred.Hmac) == 0:
+		return malformed("item is missing its key, contents or hmac"), nil
+	case cred.Digest != "" && cred.Digest != DefaultDigest:
+		return malformed("unsupported digest %q", cred.Digest), nil
+	}
+
+	_, err := c.decryptCredential(cred, ctx)
+	var integrity *IntegrityError
+	var accessDenied *AccessDeniedError
+	var contextMismatch *ContextMismatchError
+	var awsErr awserr.Error
+	switch {
+	case err == nil:
+		return nil, nil
+	case errors.As(err, &integrity):
+		return &AuditFinding{Name: cred.Name, Version: cred.Version, Problem: AuditCorrupt, Err: err}, nil
+	case errors.As(err, &accessDenied), errors.As(err, &contextMismatch):
+		return &AuditFinding{Name: cred.Name, Version: cred.Version, Problem: AuditUndecryptable, Err: err}, nil
+	case errors.As(err, &awsErr) && kmsKeyUnusableCodes[awsErr.Code()]:
+		return &AuditFinding{Name: cred.Name, Version: cred.Version, Problem: AuditUndecryptable, Err: err}, nil
+	}
+	return nil, err
+}
+
+// isPaddedVersion reports whether a version is written the way PaddedInt writes it, other
+// versions do not sort numerically and break latest version lookups
+func isPaddedVersion(version string) bool {
+	if len(version) != MaxPaddingLength {
+		return false
+	}
+	for _, r := range version {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package credstash
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyReportsFindings(t *testing.T) {
+	c, db := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "beta", "beta-1", 1)
+	mustPutSecret(t, c, "gamma", "gamma-1", 1)
+	prod := NewEncryptionContextValue()
+	(*prod)["env"] = aws.String("prod")
+	assertNoError(t, c.PutSecret("", "delta", "delta-1", c.PaddedInt(1), prod))
+
+	// Corrupt the contents of beta so the HMAC no longer matches
+	db.Item("credential-store", "beta", c.PaddedInt(1))["contents"] = &dynamodb.AttributeValue{S: aws.String("AAAA")}
+	// Store gamma under a version the Python CLI would not write
+	item := db.Item("credential-store", "gamma", c.PaddedInt(1))
+	item["version"] = &dynamodb.AttributeValue{S: aws.String("1")}
+	db.Table("credential-store")["gamma"]["1"] = item
+	delete(db.Table("credential-store")["gamma"], c.PaddedInt(1))
+
+	report, err := c.Verify("", NewEncryptionContextValue())
+
+	assertNoError(t, err)
+	assert.Equal(t, 4, report.Items)
+	assert.Equal(t, 1, report.Verified)
+	if assert.Len(t, report.Findings, 3) {
+		assert.Equal(t, "beta", report.Findings[0].Name)
+		assert.Equal(t, AuditCorrupt, report.Findings[0].Problem)
+		assert.Equal(t, "delta", report.Findings[1].Name)
+		assert.Equal(t, AuditUndecryptable, report.Findings[1].Problem)
+		assert.Equal(t, "gamma", report.Findings[2].Name)
+		assert.Equal(t, AuditMalformed, report.Findings[2].Problem)
+	}
+}
+
+func TestVerifyUnsupportedDigest(t *testing.T) {
+	c, db := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	db.Item("credential-store", "alpha", c.PaddedInt(1))["digest"] = &dynamodb.AttributeValue{S: aws.String("WHIRLPOOL")}
+
+	report, err := c.Verify("", NewEncryptionContextValue())
+
+	assertNoError(t, err)
+	if assert.Len(t, report.Findings, 1) {
+		assert.Equal(t, AuditMalformed, report.Findings[0].Problem)
+		assert.Contains(t, report.Findings[0].Err.Error(), "WHIRLPOOL")
+	}
+}
+
+func TestIsPaddedVersion(t *testing.T) {
+	c, _ := newFakeClient()
+	assert.True(t, isPaddedVersion(c.PaddedInt(42)))
+	assert.False(t, isPaddedVersion("42"))
+	assert.False(t, isPaddedVersion("000000000000000004a"))
+}
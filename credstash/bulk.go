@@ -0,0 +1,201 @@
+package credstash
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// batchGetItemLimit is the maximum number of keys DynamoDB accepts in one BatchGetItem call
+	batchGetItemLimit = 100
+
+	// maxBatchGetAttempts bounds how often unprocessed keys are retried before giving up
+	maxBatchGetAttempts = 8
+
+	// batchGetBackoff is the initial wait between unprocessed key retries, doubled on every attempt
+	batchGetBackoff = 50 * time.Millisecond
+
+	// DefaultConcurrency is the number of parallel queries and decryptions used by bulk reads
+	DefaultConcurrency = 8
+)
+
+// SecretRef identifies a secret to fetch with GetSecrets. An empty Version resolves to the
+// highest version of the secret and an empty Table to the client's default table.
+type SecretRef struct {
+	Table   string
+	Name    string
+	Version string
+	Context *EncryptionContextValue
+}
+
+// GetSecrets fetches and decrypts many secrets at once. Explicit versions are read with
+// BatchGetItem, latest versions with parallel queries, and the results are decrypted
+// concurrently. The returned credentials are in the same order as the requests.
+func (c *Client) GetSecrets(requests []SecretRef) ([]*DecryptedCredential, error) {
+	log.Printf("Getting %d secrets", len(requests))
+
+	creds := make([]*Credential, len(requests))
+	// The tables are resolved here, the caller's requests are left untouched
+	tables := make([]string, len(requests))
+	var latest []int
+	explicit := map[string][]int{}
+	for i := range requests {
+		tables[i] = c.TableName(requests[i].Table)
+		if requests[i].Version == "" {
+			latest = append(latest, i)
+		} else {
+			explicit[tables[i]] = append(explicit[tables[i]], i)
+		}
+	}
+
+	for table, indexes := range explicit {
+		found, err := c.batchGetCredentials(table, requests, indexes, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range indexes {
+			cred, ok := found[requests[i].Name+"\x00"+requests[i].Version]
+			if !ok {
+				return nil, &NotFoundError{Name: requests[i].Name, Version: requests[i].Version, Table: table}
+			}
+			creds[i] = cred
+		}
+	}
+
+	err := parallel(len(latest), DefaultConcurrency, func(n int) error {
+		i := latest[n]
+		cred, err := c.getHighestVersionCredential(tables[i], requests[i].Name)
+		if err != nil {
+			return err
+		}
+		creds[i] = cred
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]*DecryptedCredential, len(requests))
+	err = parallel(len(requests), DefaultConcurrency, func(i int) error {
+		ctx := requests[i].Context
+		if ctx == nil {
+			ctx = NewEncryptionContextValue()
+		}
+		secret, err := c.decryptCredential(creds[i], ctx)
+		if err != nil {
+			return err
+		}
+		c.recordAccess(tables[i], secret, time.Now())
+		secrets[i] = secret
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// batchGetCredentials reads the requested explicit versions from a single table, keyed by
+// name and version. Soft deleted versions are left out unless withDeleted is set. Keys DynamoDB
+// reports as unprocessed are retried with backoff.
+func (c *Client) batchGetCredentials(table string, requests []SecretRef, indexes []int, withDeleted bool) (map[string]*Credential, error) {
+	seen := map[string]bool{}
+	var keys []map[string]*dynamodb.AttributeValue
+	for _, i := range indexes {
+		id := requests[i].Name + "\x00" + requests[i].Version
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, map[string]*dynamodb.AttributeValue{
+			"name":    {S: aws.String(requests[i].Name)},
+			"version": {S: aws.String(requests[i].Version)},
+		})
+	}
+
+	found := map[string]*Credential{}
+	for start := 0; start < len(keys); start += batchGetItemLimit {
+		end := start + batchGetItemLimit
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		pending := map[string]*dynamodb.KeysAndAttributes{
+			table: {Keys: keys[start:end], ConsistentRead: aws.Bool(true)},
+		}
+		backoff := batchGetBackoff
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt == maxBatchGetAttempts {
+				return nil, fmt.Errorf("BatchGetItem on %s left %d keys unprocessed after %d attempts",
+					table, len(pending[table].Keys), attempt)
+			}
+			if attempt > 0 {
+				log.Printf("[DEBUG] Retrying %d unprocessed keys in %v", len(pending[table].Keys), backoff)
+				time.Sleep(backoff)
+				backoff *= 2
+			}
+
+			res, err := c.dynamoDB.BatchGetItem(&dynamodb.BatchGetItemInput{RequestItems: pending})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range res.Responses[table] {
+				cred := new(Credential)
+				if err := Decode(item, cred); err != nil {
+					return nil, err
+				}
+				if cred.DeletedAt != 0 && !withDeleted {
+					continue
+				}
+				found[cred.Name+"\x00"+cred.Version] = cred
+			}
+
+			pending = map[string]*dynamodb.KeysAndAttributes{}
+			if unprocessed, ok := res.UnprocessedKeys[table]; ok && len(unprocessed.Keys) > 0 {
+				pending[table] = unprocessed
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// parallel calls fn for every index in [0, n) using at most workers goroutines.
+// When several calls fail the error for the lowest index is returned.
+func parallel(n int, workers int, fn func(i int) error) error {
+	if workers > n {
+		workers = n
+	}
+
+	errs := make([]error, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
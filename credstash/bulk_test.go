@@ -0,0 +1,80 @@
+package credstash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSecretsMixesExplicitAndLatestVersions(t *testing.T) {
+	c, db := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+	mustPutSecret(t, c, "beta", "beta-1", 1)
+
+	secrets, err := c.GetSecrets([]SecretRef{
+		{Name: "alpha"},
+		{Name: "alpha", Version: c.PaddedInt(1)},
+		{Name: "beta"},
+	})
+
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-2", secrets[0].Secret)
+	assert.Equal(t, "alpha-1", secrets[1].Secret)
+	assert.Equal(t, "beta-1", secrets[2].Secret)
+	assert.Equal(t, 1, db.BatchGetCalls)
+	assert.Equal(t, 2, db.QueryCalls)
+}
+
+func TestGetSecretsLeavesRequestsUntouched(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	requests := []SecretRef{{Name: "alpha"}, {Name: "alpha", Version: c.PaddedInt(1)}}
+
+	_, err := c.GetSecrets(requests)
+
+	assertNoError(t, err)
+	assert.Equal(t, []SecretRef{{Name: "alpha"}, {Name: "alpha", Version: c.PaddedInt(1)}}, requests)
+}
+
+func TestGetSecretsRetriesUnprocessedKeys(t *testing.T) {
+	c, db := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	db.UnprocessedOnce = true
+
+	secrets, err := c.GetSecrets([]SecretRef{{Name: "alpha", Version: c.PaddedInt(1)}})
+
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-1", secrets[0].Secret)
+	assert.Equal(t, 2, db.BatchGetCalls)
+}
+
+func TestGetSecretsChunksLargeBatches(t *testing.T) {
+	c, db := newFakeClient()
+	var refs []SecretRef
+	for i := 0; i < batchGetItemLimit+5; i++ {
+		name := fmt.Sprintf("secret-%d", i)
+		mustPutSecret(t, c, name, name, 1)
+		refs = append(refs, SecretRef{Name: name, Version: c.PaddedInt(1)})
+	}
+
+	secrets, err := c.GetSecrets(refs)
+
+	assertNoError(t, err)
+	assert.Len(t, secrets, len(refs))
+	assert.Equal(t, "secret-104", secrets[104].Secret)
+	assert.Equal(t, 2, db.BatchGetCalls)
+}
+
+func TestGetSecretsMissingSecret(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+
+	_, err := c.GetSecrets([]SecretRef{{Name: "alpha"}, {Name: "missing"}})
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+
+	_, err = c.GetSecrets([]SecretRef{{Name: "alpha", Version: c.PaddedInt(2)}})
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+}
@@ -3,8 +3,10 @@ package credstash
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,14 +17,45 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/kms"
-	"github.com/secrethub/secrethub-go/pkg/randchar"
 )
 
 type Client struct {
 	table string
+	// sess is kept to derive clients for other regions, it is nil in tests
+	sess *session.Session
 
 	dynamoDB  dynamoDB
 	decrypter decrypter
+	opts      Options
+}
+
+// Options holds the optional behaviour of a Client
+type Options struct {
+	// SupersededVersionTTL sets the TTL attribute of the older versions of a secret when a new
+	// version is written, so DynamoDB deletes them after this grace period. 0 keeps them forever.
+	SupersededVersionTTL time.Duration
+	// SoftDelete makes deletes mark versions with a deleted_at tombstone instead of removing
+	// them, so they can be restored until Purge removes them
+	SoftDelete bool
+	// SoftDeleteWindow is how long a soft deleted version can be restored. It is stored on the
+	// tombstone as purge_after, Purge only falls back to it for tombstones without one.
+	SoftDeleteWindow time.Duration
+	// ProtectedPrefixes are secret name prefixes the provider protects from deletion unless
+	// told otherwise, see Protected
+	ProtectedPrefixes []string
+	// TrackAccess records the time and number of reads on every version read by GetSecret,
+	// GetHighestVersionSecret and GetSecrets, to find unused secrets
+	TrackAccess bool
+}
+
+// Protected reports whether the name starts with one of the ProtectedPrefixes
+func (c *Client) Protected(name string) bool {
+	for _, prefix := range c.opts.ProtectedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // Credential managed credential information
@@ -32,19 +65,53 @@ type Credential struct {
 	Key       string `dynamodbav:"key"`
 	Contents  string `dynamodbav:"contents"`
 	Hmac      []byte `dynamodbav:"hmac"`
+	Digest    string `dynamodbav:"digest,omitempty"`
+	Comment   string `dynamodbav:"comment,omitempty"`
 	CreatedAt int64  `dynamodbav:"created_at"`
+	// Metadata is stored unencrypted next to the secret, the Python CLI ignores it
+	Metadata map[string]string `dynamodbav:"metadata,omitempty"`
+	// ExpiresAt is the Unix time after which the version is expired, 0 when it never expires
+	ExpiresAt int64 `dynamodbav:"expires_at,omitempty"`
+	// TTL is the Unix time DynamoDB deletes a superseded version at, see Options
+	TTL int64 `dynamodbav:"ttl,omitempty"`
+	// DeletedAt is the Unix time the version was soft deleted at, 0 when it is live
+	DeletedAt int64 `dynamodbav:"deleted_at,omitempty"`
+	// PurgeAfter is the Unix time after which Purge removes a soft deleted version
+	PurgeAfter int64 `dynamodbav:"purge_after,omitempty"`
+	// LastAccessedAt is the Unix time the version was last read with TrackAccess
+	LastAccessedAt int64 `dynamodbav:"last_accessed_at,omitempty"`
+	// AccessCount is the number of reads of the version with TrackAccess
+	AccessCount int64 `dynamodbav:"access_count,omitempty"`
 }
 
 const (
 	DefaultKmsKey = "alias/credstash"
+
+	// DefaultDigest is the HMAC digest written by the Python CLI, and the only one supported
+	DefaultDigest = "SHA256"
 )
 
 func New(table string, sess *session.Session) *Client {
+	return NewWithOptions(table, sess, Options{})
+}
+
+// NewWithOptions returns a client for the default table with optional behaviour enabled
+func NewWithOptions(table string, sess *session.Session, opts Options) *Client {
 	return &Client{
 		table:     table,
+		sess:      sess,
 		decrypter: kms.New(sess),
 		dynamoDB:  dynamodb.New(sess),
+		opts:      opts,
+	}
+}
+
+// TableName returns the given table, or the client's default table when it is empty
+func (c *Client) TableName(table string) string {
+	if table == "" {
+		return c.table
 	}
+	return table
 }
 
 func (c *Client) decryptCredential(cred *Credential, ctx *EncryptionContextValue) (*DecryptedCredential, error) {
@@ -52,18 +119,18 @@ func (c *Client) decryptCredential(cred *Credential, ctx *EncryptionContextValue
 	wrappedKey, err := base64.StdEncoding.DecodeString(cred.Key)
 
 	if err != nil {
-		return nil, err
+		return nil, &IntegrityError{Name: cred.Name, Version: cred.Version, Err: fmt.Errorf("decoding key: %w", err)}
 	}
 
 	dk, err := c.DecryptDataKey(wrappedKey, ctx)
-	if awsErr, ok := err.(awserr.Error); ok {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
 		// Create reasoned responses to assist with debugging
 		switch awsErr.Code() {
 		case "AccessDeniedException":
-			err = awserr.New(awsErr.Code(), "KMS Access Denied to decrypt", nil)
-		case "InvalidCiphertextException":
-			err = awserr.New(awsErr.Code(), "The encryption context provided "+
-				"may not match the one used when the credential was stored", nil)
+			err = &AccessDeniedError{Name: cred.Name, Version: cred.Version, Err: err}
+		case kms.ErrCodeInvalidCiphertextException:
+			err = &ContextMismatchError{Name: cred.Name, Version: cred.Version, Err: err}
 		}
 	}
 	if err != nil {
@@ -75,13 +142,13 @@ func (c *Client) decryptCredential(cred *Credential, ctx *EncryptionContextValue
 
 	contents, err := base64.StdEncoding.DecodeString(cred.Contents)
 	if err != nil {
-		return nil, err
+		return nil, &IntegrityError{Name: cred.Name, Version: cred.Version, Err: fmt.Errorf("decoding contents: %w", err)}
 	}
 
 	hexhmac := ComputeHmac256(contents, hmacKey)
 
 	if !bytes.Equal(hexhmac, cred.Hmac) {
-		return nil, ErrHmacValidationFailed
+		return nil, &IntegrityError{Name: cred.Name, Version: cred.Version, Err: ErrHmacValidationFailed}
 	}
 
 	secret, err := Decrypt(dataKey, contents)
@@ -102,6 +169,21 @@ func (c *Client) GetHighestVersionSecret(table string, name string, encContext *
 		table = c.table
 	}
 
+	cred, err := c.getHighestVersionCredential(table, name)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := c.decryptCredential(cred, encContext)
+	if err != nil {
+		return nil, err
+	}
+	c.recordAccess(table, secret, time.Now())
+	return secret, nil
+}
+
+// getHighestVersionCredential fetches the still encrypted latest version of a secret
+func (c *Client) getHighestVersionCredential(table string, name string) (*Credential, error) {
 	res, err := c.dynamoDB.Query(&dynamodb.QueryInput{
 		TableName: &table,
 		ExpressionAttributeNames: map[string]*string{
@@ -125,7 +207,7 @@ func (c *Client) GetHighestVersionSecret(table string, name string, encContext *
 	cred := new(Credential)
 
 	if len(res.Items) == 0 {
-		return nil, ErrSecretNotFound
+		return nil, &NotFoundError{Name: name, Table: table}
 	}
 
 	err = Decode(res.Items[0], cred)
@@ -133,8 +215,21 @@ func (c *Client) GetHighestVersionSecret(table string, name string, encContext *
 	if err != nil {
 		return nil, err
 	}
+	if cred.DeletedAt != 0 {
+		// A single version may be soft deleted, fall back to the newest live one
+		versions, err := c.listVersions(table, name)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(versions) - 1; i >= 0; i-- {
+			if versions[i].DeletedAt == 0 {
+				return versions[i], nil
+			}
+		}
+		return nil, &NotFoundError{Name: name, Table: table}
+	}
 
-	return c.decryptCredential(cred, encContext)
+	return cred, nil
 }
 
 func (c *Client) GetSecret(name string, table string, paddedVersion string, ctx *EncryptionContextValue) (*DecryptedCredential, error) {
@@ -161,7 +256,7 @@ func (c *Client) GetSecret(name string, table string, paddedVersion string, ctx
 	log.Printf("GetSecret Items Found: %v", res)
 	if len(res.Item) == 0 {
 
-		return nil, ErrSecretNotFound
+		return nil, &NotFoundError{Name: name, Version: paddedVersion, Table: table}
 	}
 
 	err = Decode(res.Item, cred)
@@ -169,8 +264,16 @@ func (c *Client) GetSecret(name string, table string, paddedVersion string, ctx
 	if err != nil {
 		return nil, err
 	}
+	if cred.DeletedAt != 0 {
+		return nil, &NotFoundError{Name: name, Version: paddedVersion, Table: table}
+	}
 
-	return c.decryptCredential(cred, ctx)
+	secret, err := c.decryptCredential(cred, ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.recordAccess(table, secret, time.Now())
+	return secret, nil
 }
 
 // DecryptDataKey ask kms to decrypt the supplied data key
@@ -193,57 +296,67 @@ func (c *Client) DecryptDataKey(ciphertext []byte, ctx *EncryptionContextValue)
 	}, nil
 }
 
+// GenerateRandomSecret generates a password with the settings of a generate block, see GeneratePassword
 func (c *Client) GenerateRandomSecret(length int, useSymbols bool, charsets []interface{}, minRuleMap map[string]interface{}) (string, error) {
-	charset := randchar.Charset{}
-	if len(charsets) == 0 {
-		charset = randchar.Alphanumeric
-	}
-	if useSymbols {
-		charset = charset.Add(randchar.Symbols)
-	}
+	opts := PasswordOptions{Length: length, UseSymbols: useSymbols, Min: map[string]int{}}
 	for _, charsetName := range charsets {
-		set, found := randchar.CharsetByName(charsetName.(string))
-		if !found {
-			return "", fmt.Errorf("unknown charset: %s", charsetName)
-		}
-		charset = charset.Add(set)
+		opts.Charsets = append(opts.Charsets, charsetName.(string))
 	}
-	var minRules []randchar.Option
 	for charset, min := range minRuleMap {
-		n := min.(int)
-		set, found := randchar.CharsetByName(charset)
-		if !found {
-			return "", fmt.Errorf("unknown charset: %s", charset)
-		}
-		minRules = append(minRules, randchar.Min(n, set))
+		opts.Min[charset] = min.(int)
 	}
-
-	var err error
-	rand, err := randchar.NewRand(charset, minRules...)
-	if err != nil {
-		return "", err
-	}
-	byteValue, err := rand.Generate(length)
-	if err != nil {
-		return "", err
-	}
-	value := string(byteValue)
-	return value, nil
+	return c.GeneratePassword(opts)
 }
 
 func (c *Client) PutSecret(tableName string, name string, value string, paddedVersion string, ctx *EncryptionContextValue) error {
+	return c.PutSecretWithOptions(tableName, name, value, paddedVersion, ctx, PutOptions{})
+}
+
+// PutOptions holds the optional settings of PutSecretWithOptions
+type PutOptions struct {
+	// KmsKey is the KMS key used to wrap the data key, DefaultKmsKey when empty
+	KmsKey string
+	// Comment is stored alongside the secret and shown by `credstash list`
+	Comment string
+	// Metadata is stored unencrypted alongside the secret, see Credential.Metadata
+	Metadata map[string]string
+	// ExpiresAt is the Unix time the version expires at, 0 when it never expires
+	ExpiresAt int64
+	// Pinned lists versions that still have readers, Options.SupersededVersionTTL never sets
+	// their TTL
+	Pinned []string
+}
+
+// PutSecretWithOptions stores a new version of a secret. It fails if the version already exists.
+func (c *Client) PutSecretWithOptions(tableName string, name string, value string, paddedVersion string, ctx *EncryptionContextValue, opts PutOptions) error {
 	log.Print("Putting secret")
 
-	kmsKey := DefaultKmsKey
+	kmsKey := opts.KmsKey
+	if kmsKey == "" {
+		kmsKey = DefaultKmsKey
+	}
 
 	if tableName == "" {
 		tableName = c.table
 	}
 
+	cred, err := c.encryptCredential(name, value, paddedVersion, kmsKey, ctx)
+	if err != nil {
+		return err
+	}
+	cred.Comment = opts.Comment
+	cred.Metadata = opts.Metadata
+	cred.ExpiresAt = opts.ExpiresAt
+
+	return c.putCredential(tableName, cred, opts.Pinned)
+}
+
+// encryptCredential wraps a value under a fresh data key from kmsKey
+func (c *Client) encryptCredential(name string, value string, paddedVersion string, kmsKey string, ctx *EncryptionContextValue) (*Credential, error) {
 	dk, err := generateDataKey(c.decrypter, kmsKey, ctx, 64)
 	if err != nil {
 		log.Printf("[DEBUG] GenerateDataKey failed: %v", err)
-		return err
+		return nil, err
 	}
 
 	dataKey := dk.Plaintext[:32]
@@ -253,22 +366,27 @@ func (c *Client) PutSecret(tableName string, name string, value string, paddedVe
 	ctext, err := Encrypt(dataKey, []byte(value))
 	if err != nil {
 		log.Printf("[DEBUG] Encrypt failed: %v", err)
-		return err
+		return nil, err
 	}
 
 	b64hmac := ComputeHmac256(ctext, hmacKey)
 
 	b64ctext := base64.StdEncoding.EncodeToString(ctext)
 
-	cred := &Credential{
+	return &Credential{
 		Name:      name,
 		Version:   paddedVersion,
 		Key:       base64.StdEncoding.EncodeToString(wrappedKey),
 		Contents:  b64ctext,
 		Hmac:      b64hmac,
+		Digest:    DefaultDigest,
 		CreatedAt: time.Now().Unix(),
-	}
+	}, nil
+}
 
+// putCredential writes an already encrypted credential, refusing to overwrite an existing version.
+// The pinned versions keep no TTL when older versions are expired.
+func (c *Client) putCredential(tableName string, cred *Credential, pinned []string) error {
 	data, err := dynamodbattribute.MarshalMap(cred)
 
 	if err != nil {
@@ -284,17 +402,35 @@ func (c *Client) PutSecret(tableName string, name string, value string, paddedVe
 		},
 		ConditionExpression: aws.String("attribute_not_exists(#N)"),
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
-
+	if c.opts.SupersededVersionTTL > 0 {
+		// The new version is stored, failing to clean up older ones must not fail the write
+		if err := c.expireSuperseded(tableName, cred.Name, cred.Version, pinned, time.Now()); err != nil {
+			log.Printf("[WARN] Setting the TTL of superseded versions of %s: %v", cred.Name, err)
+		}
+	}
+	return nil
 }
 
 func (c *Client) DeleteSecret(tableName string, name string) error {
+	_, err := c.DeleteSecretVersions(tableName, name)
+	return err
+}
+
+// DeleteSecretVersions deletes every version of a secret and returns the deleted versions.
+// With SoftDelete the live versions are marked deleted instead, see Restore and Purge.
+func (c *Client) DeleteSecretVersions(tableName string, name string) ([]string, error) {
 	log.Print("Deleting secret")
 
 	if tableName == "" {
 		tableName = c.table
 	}
+	if c.opts.SoftDelete {
+		return c.softDelete(tableName, name, time.Now())
+	}
 
 	res, err := c.dynamoDB.Query(&dynamodb.QueryInput{
 		TableName: &tableName,
@@ -312,37 +448,110 @@ func (c *Client) DeleteSecret(tableName string, name string) error {
 	})
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var deleted []string
 	for _, item := range res.Items {
 		cred := new(Credential)
 
 		err = Decode(item, cred)
 		if err != nil {
-			return err
+			return deleted, err
+		}
+
+		if err := c.deleteCredential(tableName, cred.Name, cred.Version); err != nil {
+			return deleted, err
 		}
+		deleted = append(deleted, cred.Version)
+	}
+
+	return deleted, nil
+}
 
-		log.Printf("[DEBUG] Deleting name: %s version: %v", cred.Name, cred.Version)
-
-		_, err = c.dynamoDB.DeleteItem(&dynamodb.DeleteItemInput{
-			TableName: &tableName,
-			Key: map[string]*dynamodb.AttributeValue{
-				"name": {
-					S: aws.String(cred.Name),
-				},
-				"version": {
-					S: aws.String(cred.Version),
-				},
+// DeleteSecretVersion deletes a single version of a secret, a missing version is not an error.
+// With SoftDelete the version is marked deleted instead, see Restore and Purge.
+func (c *Client) DeleteSecretVersion(tableName string, name string, paddedVersion string) error {
+	tableName = c.TableName(tableName)
+	if c.opts.SoftDelete {
+		err := c.softDeleteVersion(tableName, name, paddedVersion, time.Now())
+		if errors.Is(err, ErrSecretNotFound) {
+			return nil
+		}
+		return err
+	}
+	return c.deleteCredential(tableName, name, paddedVersion)
+}
+
+// deleteCredential removes a version of a secret from the table
+func (c *Client) deleteCredential(tableName string, name string, paddedVersion string) error {
+	log.Printf("[DEBUG] Deleting name: %s version: %v", name, paddedVersion)
+
+	_, err := c.dynamoDB.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: &tableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			"name": {
+				S: aws.String(name),
+			},
+			"version": {
+				S: aws.String(paddedVersion),
 			},
-		})
+		},
+	})
+	return err
+}
+
+// ListSecrets returns the name, version and comment of every item in the table that is not
+// soft deleted, sorted by name and version like `credstash list`
+func (c *Client) ListSecrets(tableName string) ([]*Credential, error) {
+	log.Print("Listing secrets")
+
+	if tableName == "" {
+		tableName = c.table
+	}
 
+	var creds []*Credential
+	input := &dynamodb.ScanInput{
+		TableName: &tableName,
+		ExpressionAttributeNames: map[string]*string{
+			"#N": aws.String("name"),
+			"#V": aws.String("version"),
+			"#C": aws.String("comment"),
+			"#D": aws.String("deleted_at"),
+		},
+		ProjectionExpression: aws.String("#N, #V, #C, #D"),
+	}
+	for {
+		res, err := c.dynamoDB.Scan(input)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		for _, item := range res.Items {
+			cred := new(Credential)
+			if err := Decode(item, cred); err != nil {
+				return nil, err
+			}
+			if cred.DeletedAt != 0 {
+				continue
+			}
+			creds = append(creds, cred)
 		}
+
+		if len(res.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = res.LastEvaluatedKey
 	}
 
-	return nil
+	sort.Slice(creds, func(i, j int) bool {
+		if creds[i].Name != creds[j].Name {
+			return creds[i].Name < creds[j].Name
+		}
+		return creds[i].Version < creds[j].Version
+	})
+
+	return creds, nil
 }
 
 const MaxPaddingLength = 19 // Number of digits in MaxInt64
@@ -354,6 +563,21 @@ func (c *Client) PaddedInt(i int) string {
 	return strings.Repeat("0", padLength) + strconv.Itoa(i)
 }
 
+// GetLatestVersion returns the highest version number stored for a secret, failing with
+// ErrSecretNotFound when it is soft deleted
+func (c *Client) GetLatestVersion(tableName string, name string) (int, error) {
+	if tableName == "" {
+		tableName = c.table
+	}
+
+	cred, err := c.getHighestVersionCredential(tableName, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(cred.Version)
+}
+
 // ResolveVersion converts an integer version to a string, or if a version isn't provided (0),
 // returns "1" if the secret doesn't exist or the latest version plus one (auto-increment) if it does.
 func (c *Client) ResolveVersion(tableName string, name string, version int) (string, error) {
@@ -369,7 +593,7 @@ func (c *Client) ResolveVersion(tableName string, name string, version int) (str
 
 	ver, err := GetHighestVersion(c.dynamoDB, &tableName, name)
 	if err != nil {
-		if err == ErrSecretNotFound {
+		if errors.Is(err, ErrSecretNotFound) {
 			return c.PaddedInt(1), nil
 		}
 		return "", err
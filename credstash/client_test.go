@@ -0,0 +1,95 @@
+package credstash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLatestVersion(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-12", 12)
+
+	version, err := c.GetLatestVersion("", "alpha")
+	assertNoError(t, err)
+	assert.Equal(t, 12, version)
+
+	_, err = c.GetLatestVersion("", "missing")
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+}
+
+func TestPutSecretWithOptions(t *testing.T) {
+	c, db := newFakeClient()
+
+	err := c.PutSecretWithOptions("", "alpha", "alpha-1", c.PaddedInt(1), NewEncryptionContextValue(), PutOptions{
+		KmsKey:  "alias/other",
+		Comment: "rotated by hand",
+	})
+	assertNoError(t, err)
+
+	item := db.Item("credential-store", "alpha", c.PaddedInt(1))
+	assert.Equal(t, "rotated by hand", *item["comment"].S)
+	assert.Equal(t, DefaultDigest, *item["digest"].S)
+
+	secret, err := c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-1", secret.Secret)
+
+	err = c.PutSecret("", "alpha", "again", c.PaddedInt(1), NewEncryptionContextValue())
+	assertError(t, err)
+}
+
+func TestListSecretsPaginates(t *testing.T) {
+	c, db := newFakeClient()
+	mustPutSecret(t, c, "beta", "beta-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	db.ScanPageSize = 2
+
+	creds, err := c.ListSecrets("")
+
+	assertNoError(t, err)
+	assert.Equal(t, 2, db.ScanCalls)
+	assert.Len(t, creds, 3)
+	assert.Equal(t, "alpha", creds[0].Name)
+	assert.Equal(t, c.PaddedInt(2), creds[1].Version)
+	assert.Equal(t, "beta", creds[2].Name)
+}
+
+func TestDeleteSecretVersions(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+
+	deleted, err := c.DeleteSecretVersions("", "alpha")
+
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(2), c.PaddedInt(1)}, deleted)
+	_, err = c.GetLatestVersion("", "alpha")
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+}
+
+func TestDeleteSecretVersion(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+
+	assertNoError(t, c.DeleteSecretVersion("", "alpha", c.PaddedInt(2)))
+	assertNoError(t, c.DeleteSecretVersion("", "alpha", c.PaddedInt(3)))
+
+	latest, err := c.GetLatestVersion("", "alpha")
+	assertNoError(t, err)
+	assert.Equal(t, 1, latest)
+}
+
+func TestProtected(t *testing.T) {
+	c, _ := newFakeClient()
+	assert.False(t, c.Protected("prod/db"))
+
+	c.opts.ProtectedPrefixes = []string{"prod/", "shared."}
+	assert.True(t, c.Protected("prod/db"))
+	assert.True(t, c.Protected("shared.smtp"))
+	assert.False(t, c.Protected("dev/db"))
+}
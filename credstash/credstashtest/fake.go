@@ -0,0 +1,385 @@
+// Package credstashtest provides in-memory fakes of the DynamoDB and KMS APIs used by the
+// credstash client, for tests of the client and of the provider.
+package credstashtest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// Session returns an AWS session whose DynamoDB and KMS calls are answered by db and k
+// instead of being sent, so clients created from it with credstash.New use the fakes
+func Session(db *DynamoDB, k *KMS) *session.Session {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	sess.Handlers.Send.Clear()
+	sess.Handlers.Send.PushBack(func(r *request.Request) {
+		method := reflect.ValueOf(db).MethodByName(r.Operation.Name)
+		if r.ClientInfo.ServiceName == kms.ServiceName {
+			method = reflect.ValueOf(k).MethodByName(r.Operation.Name)
+		}
+		if !method.IsValid() {
+			r.Error = awserr.New("UnsupportedOperation", "the fake does not support "+r.Operation.Name, nil)
+			return
+		}
+		out := method.Call([]reflect.Value{reflect.ValueOf(r.Params)})
+		if err := out[1]; !err.IsNil() {
+			r.Error = err.Interface().(error)
+			return
+		}
+		// The output goes through the SDK's own unmarshalling, like a real response
+		body, err := jsonutil.BuildJSON(out[0].Interface())
+		if err != nil {
+			r.Error = err
+			return
+		}
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		}
+	})
+	return sess
+}
+
+// DynamoDB is an in-memory stand-in for the subset of DynamoDB used by the client.
+// Items are keyed by table, then name, then padded version.
+type DynamoDB struct {
+	mu     sync.Mutex
+	tables map[string]map[string]map[string]map[string]*dynamodb.AttributeValue
+
+	// UnprocessedOnce makes the first BatchGetItem call return every key as unprocessed
+	UnprocessedOnce bool
+	BatchGetCalls   int
+	QueryCalls      int
+
+	// ScanPageSize limits the number of items returned by one Scan call, 0 means unlimited
+	ScanPageSize int
+	ScanCalls    int
+	Tags         map[string][]*dynamodb.Tag
+	TTLAttribute map[string]string
+}
+
+// NewDynamoDB returns an empty fake
+func NewDynamoDB() *DynamoDB {
+	return &DynamoDB{tables: map[string]map[string]map[string]map[string]*dynamodb.AttributeValue{}}
+}
+
+// Table returns the items of a table keyed by name and version, creating it when missing
+func (f *DynamoDB) Table(name string) map[string]map[string]map[string]*dynamodb.AttributeValue {
+	t, ok := f.tables[name]
+	if !ok {
+		t = map[string]map[string]map[string]*dynamodb.AttributeValue{}
+		f.tables[name] = t
+	}
+	return t
+}
+
+// Item returns a stored item, nil when there is none
+func (f *DynamoDB) Item(table, name, version string) map[string]*dynamodb.AttributeValue {
+	return f.Table(table)[name][version]
+}
+
+func (f *DynamoDB) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.StringValue(in.Item["name"].S)
+	version := aws.StringValue(in.Item["version"].S)
+	t := f.Table(aws.StringValue(in.TableName))
+	if in.ConditionExpression != nil && t[name][version] != nil {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+	if t[name] == nil {
+		t[name] = map[string]map[string]*dynamodb.AttributeValue{}
+	}
+	out := &dynamodb.PutItemOutput{}
+	if aws.StringValue(in.ReturnValues) == dynamodb.ReturnValueAllOld {
+		out.Attributes = t[name][version]
+	}
+	t[name][version] = in.Item
+	return out, nil
+}
+
+func (f *DynamoDB) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.StringValue(in.Key["name"].S)
+	version := aws.StringValue(in.Key["version"].S)
+	return &dynamodb.GetItemOutput{Item: f.Item(aws.StringValue(in.TableName), name, version)}, nil
+}
+
+func (f *DynamoDB) DeleteItem(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.StringValue(in.Key["name"].S)
+	version := aws.StringValue(in.Key["version"].S)
+	// Only `attribute_exists(#A)` conditions are supported
+	if condition := aws.StringValue(in.ConditionExpression); condition != "" {
+		placeholder := strings.TrimSuffix(strings.TrimPrefix(condition, "attribute_exists("), ")")
+		attribute := aws.StringValue(in.ExpressionAttributeNames[placeholder])
+		if f.Item(aws.StringValue(in.TableName), name, version)[attribute] == nil {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+		}
+	}
+	delete(f.Table(aws.StringValue(in.TableName))[name], version)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+// UpdateItem supports `SET #A = :v`, `REMOVE #A` and `ADD #A :n` clauses of single attributes
+func (f *DynamoDB) UpdateItem(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.StringValue(in.Key["name"].S)
+	version := aws.StringValue(in.Key["version"].S)
+	item := f.Item(aws.StringValue(in.TableName), name, version)
+	if item == nil {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+
+	updated := map[string]*dynamodb.AttributeValue{}
+	fields := strings.Fields(aws.StringValue(in.UpdateExpression))
+	for i := 0; i < len(fields); {
+		attribute := aws.StringValue(in.ExpressionAttributeNames[fields[i+1]])
+		switch fields[i] {
+		case "SET":
+			item[attribute] = in.ExpressionAttributeValues[fields[i+3]]
+			updated[attribute] = item[attribute]
+			i += 4
+		case "ADD":
+			var current int64
+			if v := item[attribute]; v != nil {
+				current, _ = strconv.ParseInt(aws.StringValue(v.N), 10, 64)
+			}
+			n, _ := strconv.ParseInt(aws.StringValue(in.ExpressionAttributeValues[fields[i+2]].N), 10, 64)
+			item[attribute] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(current+n, 10))}
+			updated[attribute] = item[attribute]
+			i += 3
+		default:
+			delete(item, attribute)
+			i += 2
+		}
+	}
+
+	out := &dynamodb.UpdateItemOutput{}
+	if aws.StringValue(in.ReturnValues) == dynamodb.ReturnValueUpdatedNew {
+		out.Attributes = updated
+	}
+	return out, nil
+}
+
+func (f *DynamoDB) Query(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.QueryCalls++
+
+	name := aws.StringValue(in.ExpressionAttributeValues[":name"].S)
+	versions := f.Table(aws.StringValue(in.TableName))[name]
+
+	keys := make([]string, 0, len(versions))
+	for v := range versions {
+		keys = append(keys, v)
+	}
+	sort.Strings(keys)
+	if !aws.BoolValue(in.ScanIndexForward) && in.ScanIndexForward != nil {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	out := &dynamodb.QueryOutput{}
+	for _, k := range keys {
+		if in.Limit != nil && int64(len(out.Items)) >= *in.Limit {
+			break
+		}
+		out.Items = append(out.Items, versions[k])
+	}
+	return out, nil
+}
+
+func (f *DynamoDB) BatchGetItem(in *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.BatchGetCalls++
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]*dynamodb.AttributeValue{},
+		UnprocessedKeys: map[string]*dynamodb.KeysAndAttributes{},
+	}
+	for table, keys := range in.RequestItems {
+		if f.UnprocessedOnce {
+			out.UnprocessedKeys[table] = keys
+			continue
+		}
+		for _, key := range keys.Keys {
+			item := f.Item(table, aws.StringValue(key["name"].S), aws.StringValue(key["version"].S))
+			if item != nil {
+				out.Responses[table] = append(out.Responses[table], item)
+			}
+		}
+	}
+	f.UnprocessedOnce = false
+	return out, nil
+}
+
+func (f *DynamoDB) Scan(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ScanCalls++
+
+	t := f.Table(aws.StringValue(in.TableName))
+	var ids []string
+	for name, versions := range t {
+		for version := range versions {
+			ids = append(ids, name+"\x00"+version)
+		}
+	}
+	sort.Strings(ids)
+
+	start := ""
+	if in.ExclusiveStartKey != nil {
+		start = aws.StringValue(in.ExclusiveStartKey["name"].S) + "\x00" + aws.StringValue(in.ExclusiveStartKey["version"].S)
+	}
+
+	out := &dynamodb.ScanOutput{}
+	for _, id := range ids {
+		if id <= start {
+			continue
+		}
+		if f.ScanPageSize > 0 && len(out.Items) == f.ScanPageSize {
+			out.LastEvaluatedKey = out.Items[len(out.Items)-1]
+			break
+		}
+		parts := strings.SplitN(id, "\x00", 2)
+		out.Items = append(out.Items, t[parts[0]][parts[1]])
+	}
+	out.Count = aws.Int64(int64(len(out.Items)))
+	return out, nil
+}
+
+func (f *DynamoDB) CreateTable(in *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Table(aws.StringValue(in.TableName))
+	return &dynamodb.CreateTableOutput{TableDescription: &dynamodb.TableDescription{
+		TableName: in.TableName,
+		TableArn:  aws.String("arn:aws:dynamodb:us-east-1:123456789012:table/" + aws.StringValue(in.TableName)),
+	}}, nil
+}
+
+func (f *DynamoDB) DescribeTable(in *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.tables[aws.StringValue(in.TableName)]; !ok {
+		return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Requested resource not found", nil)
+	}
+	return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{TableName: in.TableName}}, nil
+}
+
+func (f *DynamoDB) WaitUntilTableExists(in *dynamodb.DescribeTableInput) error {
+	_, err := f.DescribeTable(in)
+	return err
+}
+
+func (f *DynamoDB) TagResource(in *dynamodb.TagResourceInput) (*dynamodb.TagResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Tags == nil {
+		f.Tags = map[string][]*dynamodb.Tag{}
+	}
+	f.Tags[aws.StringValue(in.ResourceArn)] = in.Tags
+	return &dynamodb.TagResourceOutput{}, nil
+}
+
+// KMS fakes data key generation and decryption. The "ciphertext" of a data key is the
+// key id and encryption context followed by the plaintext, so a mismatched context on
+// decrypt yields an InvalidCiphertextException like the real service.
+type KMS struct {
+	mu      sync.Mutex
+	counter byte
+
+	// DenyDecrypt makes every Decrypt call fail with an AccessDeniedException
+	DenyDecrypt bool
+}
+
+func kmsHeader(keyID string, ctx map[string]*string) []byte {
+	keys := make([]string, 0, len(ctx))
+	for k := range ctx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(keyID)
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + aws.StringValue(ctx[k]))
+	}
+	b.WriteString("#")
+	return []byte(b.String())
+}
+
+func (f *KMS) GenerateDataKey(in *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counter++
+
+	plaintext := bytes.Repeat([]byte{f.counter}, int(aws.Int64Value(in.NumberOfBytes)))
+	blob := append(kmsHeader(aws.StringValue(in.KeyId), in.EncryptionContext), plaintext...)
+	return &kms.GenerateDataKeyOutput{
+		CiphertextBlob: blob,
+		Plaintext:      plaintext,
+		KeyId:          in.KeyId,
+	}, nil
+}
+
+func (f *KMS) Decrypt(in *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	if f.DenyDecrypt {
+		return nil, awserr.New("AccessDeniedException", "not authorized to perform kms:Decrypt", nil)
+	}
+	sep := bytes.IndexByte(in.CiphertextBlob, '#')
+	if sep < 0 {
+		return nil, awserr.New(kms.ErrCodeInvalidCiphertextException, "", nil)
+	}
+	keyID := string(in.CiphertextBlob[:bytes.IndexAny(in.CiphertextBlob, "|#")])
+	if !bytes.Equal(in.CiphertextBlob[:sep+1], kmsHeader(keyID, in.EncryptionContext)) {
+		return nil, awserr.New(kms.ErrCodeInvalidCiphertextException, "", nil)
+	}
+	return &kms.DecryptOutput{
+		KeyId:     aws.String(keyID),
+		Plaintext: in.CiphertextBlob[sep+1:],
+	}, nil
+}
+
+func (f *DynamoDB) UpdateTimeToLive(in *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.TTLAttribute == nil {
+		f.TTLAttribute = map[string]string{}
+	}
+	f.TTLAttribute[aws.StringValue(in.TableName)] = aws.StringValue(in.TimeToLiveSpecification.AttributeName)
+	return &dynamodb.UpdateTimeToLiveOutput{}, nil
+}
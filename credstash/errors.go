@@ -0,0 +1,95 @@
+package credstash
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotFoundError is returned when a secret, or the requested version of it, does not exist.
+// It matches ErrSecretNotFound with errors.Is.
+type NotFoundError struct {
+	Name    string
+	Version string
+	Table   string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf("secret %q not found in table %q", e.Name, e.Table)
+	}
+	return fmt.Sprintf("secret %q version %s not found in table %q", e.Name, displayVersion(e.Version), e.Table)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return ErrSecretNotFound
+}
+
+// AccessDeniedError is returned when KMS refuses to decrypt the data key of a secret
+type AccessDeniedError struct {
+	Name    string
+	Version string
+	Err     error
+}
+
+func (e *AccessDeniedError) Error() string {
+	return fmt.Sprintf("KMS access denied decrypting secret %q version %s: %v", e.Name, displayVersion(e.Version), e.Err)
+}
+
+func (e *AccessDeniedError) Unwrap() error {
+	return e.Err
+}
+
+// ContextMismatchError is returned when KMS rejects the data key of a secret, which almost
+// always means the encryption context differs from the one used when it was stored
+type ContextMismatchError struct {
+	Name    string
+	Version string
+	Err     error
+}
+
+func (e *ContextMismatchError) Error() string {
+	return fmt.Sprintf("KMS could not decrypt secret %q version %s, the encryption context "+
+		"may not match the one used when it was stored: %v", e.Name, displayVersion(e.Version), e.Err)
+}
+
+func (e *ContextMismatchError) Unwrap() error {
+	return e.Err
+}
+
+// ExpiredError is returned by Credential.CheckExpiry for a version read past its expiry
+type ExpiredError struct {
+	Name      string
+	Version   string
+	ExpiresAt time.Time
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("secret %q version %s expired at %s", e.Name, displayVersion(e.Version), e.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// IntegrityError is returned when a stored item is malformed or fails HMAC validation.
+// HMAC failures match ErrHmacValidationFailed with errors.Is.
+type IntegrityError struct {
+	Name    string
+	Version string
+	Err     error
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for secret %q version %s: %v", e.Name, displayVersion(e.Version), e.Err)
+}
+
+func (e *IntegrityError) Unwrap() error {
+	return e.Err
+}
+
+// displayVersion strips the zero padding from a version for use in messages
+func displayVersion(paddedVersion string) string {
+	trimmed := strings.TrimLeft(paddedVersion, "0")
+	if _, err := strconv.Atoi(trimmed); err != nil || trimmed == "" {
+		return paddedVersion
+	}
+	return trimmed
+}
@@ -0,0 +1,68 @@
+package credstash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/granular-oss/terraform-provider-credstash/credstash/credstashtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFoundErrorMatchesSentinel(t *testing.T) {
+	c, _ := newFakeClient()
+
+	_, err := c.GetHighestVersionSecret("", "missing", NewEncryptionContextValue())
+
+	var notFound *NotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+	assert.Equal(t, "missing", notFound.Name)
+	assert.Equal(t, "credential-store", notFound.Table)
+
+	_, err = c.GetSecret("missing", "", c.PaddedInt(3), NewEncryptionContextValue())
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, `secret "missing" version 3 not found in table "credential-store"`, err.Error())
+}
+
+func TestContextMismatchError(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+
+	ctx := NewEncryptionContextValue()
+	(*ctx)["env"] = aws.String("prod")
+	_, err := c.GetHighestVersionSecret("", "alpha", ctx)
+
+	var mismatch *ContextMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "alpha", mismatch.Name)
+
+	var awsErr awserr.Error
+	assert.True(t, errors.As(err, &awsErr))
+	assert.Equal(t, "InvalidCiphertextException", awsErr.Code())
+}
+
+func TestAccessDeniedError(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	c.decrypter.(*credstashtest.KMS).DenyDecrypt = true
+
+	_, err := c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+
+	var denied *AccessDeniedError
+	assert.True(t, errors.As(err, &denied))
+	assert.Equal(t, c.PaddedInt(1), denied.Version)
+}
+
+func TestIntegrityErrorOnTamperedHmac(t *testing.T) {
+	c, db := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	db.Item("credential-store", "alpha", c.PaddedInt(1))["hmac"].B[0] ^= 0xff
+
+	_, err := c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+
+	var integrity *IntegrityError
+	assert.True(t, errors.As(err, &integrity))
+	assert.True(t, errors.Is(err, ErrHmacValidationFailed))
+}
@@ -0,0 +1,37 @@
+package credstash
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Expiry returns the time the version expires at, and false when it never expires
+func (c *Credential) Expiry() (time.Time, bool) {
+	if c.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(c.ExpiresAt, 0), true
+}
+
+// CheckExpiry returns an ExpiredError when the version expired before now
+func (c *Credential) CheckExpiry(now time.Time) error {
+	if expiry, ok := c.Expiry(); ok && !now.Before(expiry) {
+		return &ExpiredError{Name: c.Name, Version: c.Version, ExpiresAt: expiry}
+	}
+	return nil
+}
+
+// UpdateExpiry changes the expiry of one version of a secret in place, 0 removes it
+func (c *Client) UpdateExpiry(tableName string, name string, paddedVersion string, expiresAt int64) error {
+	log.Printf("Updating expiry: %s", name)
+
+	var value *dynamodb.AttributeValue
+	if expiresAt != 0 {
+		value = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expiresAt, 10))}
+	}
+	return c.updateAttribute(tableName, name, paddedVersion, "expires_at", value)
+}
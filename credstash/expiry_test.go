@@ -0,0 +1,41 @@
+package credstash
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	assert.NoError(t, (&Credential{Name: "app.db"}).CheckExpiry(now))
+	assert.NoError(t, (&Credential{Name: "app.db", ExpiresAt: now.Unix() + 1}).CheckExpiry(now))
+
+	err := (&Credential{Name: "app.db", Version: "0000000000000000001", ExpiresAt: now.Unix()}).CheckExpiry(now)
+	var expired *ExpiredError
+	assert.True(t, errors.As(err, &expired), err)
+	assert.Equal(t, `secret "app.db" version 1 expired at 2023-11-14T22:13:20Z`, err.Error())
+}
+
+func TestUpdateExpiry(t *testing.T) {
+	c, _ := newFakeClient()
+	err := c.PutSecretWithOptions("", "app.db", "hunter2", c.PaddedInt(1), NewEncryptionContextValue(), PutOptions{ExpiresAt: 1700000000})
+	assertNoError(t, err)
+	secret, err := c.GetSecret("app.db", "", c.PaddedInt(1), NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, int64(1700000000), secret.ExpiresAt)
+
+	assertNoError(t, c.UpdateExpiry("", "app.db", c.PaddedInt(1), 1800000000))
+	secret, err = c.GetSecret("app.db", "", c.PaddedInt(1), NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, int64(1800000000), secret.ExpiresAt)
+
+	assertNoError(t, c.UpdateExpiry("", "app.db", c.PaddedInt(1), 0))
+	secret, err = c.GetSecret("app.db", "", c.PaddedInt(1), NewEncryptionContextValue())
+	assertNoError(t, err)
+	_, expires := secret.Expiry()
+	assert.False(t, expires)
+}
@@ -0,0 +1,338 @@
+package credstash
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// ExportFormat identifies credstash export archives
+	ExportFormat = "credstash-export"
+	// ExportFormatVersion is the archive layout written by Export
+	ExportFormatVersion = 1
+)
+
+// ConflictMode decides what Import does with items whose name and version already exist
+type ConflictMode string
+
+const (
+	// ConflictSkip keeps the existing item
+	ConflictSkip ConflictMode = "skip"
+	// ConflictOverwrite replaces the existing item with the archived one
+	ConflictOverwrite ConflictMode = "overwrite"
+	// ConflictFail aborts the import before anything is written
+	ConflictFail ConflictMode = "fail"
+)
+
+// ExportManifest is the first record of an archive
+type ExportManifest struct {
+	Format        string `json:"format"`
+	FormatVersion int    `json:"format_version"`
+	Table         string `json:"table"`
+	ExportedAt    string `json:"exported_at"`
+}
+
+// ExportTrailer is the last record of an archive. Its checksum is the SHA-256 of all item
+// checksums in order, so truncated or reordered archives are rejected.
+type ExportTrailer struct {
+	Items  int    `json:"items"`
+	SHA256 string `json:"sha256"`
+}
+
+// exportRecord is one line of an archive, holding exactly one of its fields
+type exportRecord struct {
+	Manifest *ExportManifest             `json:"manifest,omitempty"`
+	Item     map[string]*exportAttribute `json:"item,omitempty"`
+	SHA256   string                      `json:"sha256,omitempty"`
+	Trailer  *ExportTrailer              `json:"trailer,omitempty"`
+}
+
+// exportAttribute is a DynamoDB attribute value in the same shape as the DynamoDB JSON format
+type exportAttribute struct {
+	B    []byte                      `json:"B,omitempty"`
+	BOOL *bool                       `json:"BOOL,omitempty"`
+	BS   [][]byte                    `json:"BS,omitempty"`
+	L    []*exportAttribute          `json:"L,omitempty"`
+	M    map[string]*exportAttribute `json:"M,omitempty"`
+	N    *string                     `json:"N,omitempty"`
+	NS   []*string                   `json:"NS,omitempty"`
+	NULL *bool                       `json:"NULL,omitempty"`
+	S    *string                     `json:"S,omitempty"`
+	SS   []*string                   `json:"SS,omitempty"`
+}
+
+// ImportResult counts what Import did with the archived items
+type ImportResult struct {
+	Imported    int
+	Overwritten int
+	Skipped     int
+}
+
+// Export streams every item of the table, still encrypted, to w as JSON lines: a manifest,
+// one record per item with its checksum, and a trailer. It returns the number of items.
+func (c *Client) Export(tableName string, w io.Writer) (int, error) {
+	tableName = c.TableName(tableName)
+	log.Printf("Exporting table: %s", tableName)
+
+	enc := json.NewEncoder(w)
+	err := enc.Encode(exportRecord{Manifest: &ExportManifest{
+		Format:        ExportFormat,
+		FormatVersion: ExportFormatVersion,
+		Table:         tableName,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+	}})
+	if err != nil {
+		return 0, err
+	}
+
+	total := sha256.New()
+	count := 0
+	input := &dynamodb.ScanInput{TableName: &tableName, ConsistentRead: aws.Bool(true)}
+	for {
+		res, err := c.dynamoDB.Scan(input)
+		if err != nil {
+			return count, err
+		}
+
+		for _, item := range res.Items {
+			record := exportRecord{Item: toExportItem(item)}
+			record.SHA256, err = itemChecksum(record.Item)
+			if err != nil {
+				return count, err
+			}
+			if err := enc.Encode(record); err != nil {
+				return count, err
+			}
+			total.Write([]byte(record.SHA256))
+			count++
+		}
+
+		if len(res.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+
+	err = enc.Encode(exportRecord{Trailer: &ExportTrailer{
+		Items:  count,
+		SHA256: hex.EncodeToString(total.Sum(nil)),
+	}})
+	return count, err
+}
+
+// Import restores an archive written by Export into the table. The whole archive is read and
+// its checksums verified before anything is written.
+func (c *Client) Import(tableName string, r io.Reader, mode ConflictMode) (*ImportResult, error) {
+	tableName = c.TableName(tableName)
+	log.Printf("Importing into table: %s", tableName)
+
+	switch mode {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+	default:
+		return nil, fmt.Errorf("unknown conflict mode %q", mode)
+	}
+
+	items, err := readExport(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == ConflictFail {
+		if err := c.checkImportConflicts(tableName, items); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ImportResult{}
+	for _, item := range items {
+		input := &dynamodb.PutItemInput{TableName: &tableName, Item: item}
+		if mode == ConflictOverwrite {
+			input.ReturnValues = aws.String(dynamodb.ReturnValueAllOld)
+		} else {
+			input.ExpressionAttributeNames = map[string]*string{"#N": aws.String("name")}
+			input.ConditionExpression = aws.String("attribute_not_exists(#N)")
+		}
+
+		res, err := c.dynamoDB.PutItem(input)
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			if mode == ConflictSkip {
+				result.Skipped++
+				continue
+			}
+			return result, fmt.Errorf("%s version %s already exists", aws.StringValue(item["name"].S), aws.StringValue(item["version"].S))
+		}
+		if err != nil {
+			return result, err
+		}
+
+		if res != nil && len(res.Attributes) > 0 {
+			result.Overwritten++
+		} else {
+			result.Imported++
+		}
+	}
+
+	return result, nil
+}
+
+// checkImportConflicts fails when any archived name and version already exists in the table.
+// Soft deleted versions count, the conditional writes of the import fail on them too.
+func (c *Client) checkImportConflicts(tableName string, items []map[string]*dynamodb.AttributeValue) error {
+	refs := make([]SecretRef, len(items))
+	indexes := make([]int, len(items))
+	for i, item := range items {
+		refs[i] = SecretRef{Name: aws.StringValue(item["name"].S), Version: aws.StringValue(item["version"].S)}
+		indexes[i] = i
+	}
+
+	existing, err := c.batchGetCredentials(tableName, refs, indexes, true)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if _, ok := existing[ref.Name+"\x00"+ref.Version]; ok {
+			return fmt.Errorf("%s version %s already exists in table %s", ref.Name, ref.Version, tableName)
+		}
+	}
+	return nil
+}
+
+// readExport parses and verifies an archive, returning its items
+func readExport(r io.Reader) ([]map[string]*dynamodb.AttributeValue, error) {
+	scanner := bufio.NewScanner(r)
+	// Items are limited to 400KB by DynamoDB, base64 and JSON add some overhead
+	scanner.Buffer(make([]byte, 64*1024), 2*1024*1024)
+
+	var manifest *ExportManifest
+	var trailer *ExportTrailer
+	var items []map[string]*dynamodb.AttributeValue
+	total := sha256.New()
+	line := 0
+	for scanner.Scan() {
+		line++
+		var record exportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch {
+		case trailer != nil:
+			return nil, fmt.Errorf("line %d: unexpected record after the trailer", line)
+		case line == 1:
+			if record.Manifest == nil || record.Manifest.Format != ExportFormat {
+				return nil, fmt.Errorf("not a %s archive", ExportFormat)
+			}
+			if record.Manifest.FormatVersion != ExportFormatVersion {
+				return nil, fmt.Errorf("unsupported archive format version %d", record.Manifest.FormatVersion)
+			}
+			manifest = record.Manifest
+		case record.Trailer != nil:
+			trailer = record.Trailer
+		case record.Item != nil:
+			sum, err := itemChecksum(record.Item)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if sum != record.SHA256 {
+				return nil, fmt.Errorf("line %d: checksum mismatch", line)
+			}
+			item := fromExportItem(record.Item)
+			if item["name"] == nil || item["name"].S == nil || item["version"] == nil || item["version"].S == nil {
+				return nil, fmt.Errorf("line %d: item without name or version", line)
+			}
+			total.Write([]byte(sum))
+			items = append(items, item)
+		default:
+			return nil, fmt.Errorf("line %d: empty record", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("not a %s archive", ExportFormat)
+	}
+	if trailer == nil {
+		return nil, errors.New("archive is truncated, the trailer is missing")
+	}
+	if trailer.Items != len(items) {
+		return nil, fmt.Errorf("archive item count mismatch, the trailer lists %d items and %d were read", trailer.Items, len(items))
+	}
+	if trailer.SHA256 != hex.EncodeToString(total.Sum(nil)) {
+		return nil, errors.New("archive checksum mismatch, the items do not match the trailer checksum")
+	}
+	return items, nil
+}
+
+func itemChecksum(item map[string]*exportAttribute) (string, error) {
+	// Maps are marshalled with sorted keys, so the encoding is canonical
+	b, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func toExportItem(item map[string]*dynamodb.AttributeValue) map[string]*exportAttribute {
+	out := make(map[string]*exportAttribute, len(item))
+	for k, v := range item {
+		out[k] = toExportAttribute(v)
+	}
+	return out
+}
+
+func toExportAttribute(v *dynamodb.AttributeValue) *exportAttribute {
+	if v == nil {
+		return nil
+	}
+	a := &exportAttribute{B: v.B, BOOL: v.BOOL, BS: v.BS, N: v.N, NS: v.NS, NULL: v.NULL, S: v.S, SS: v.SS}
+	if v.L != nil {
+		a.L = make([]*exportAttribute, len(v.L))
+		for i, e := range v.L {
+			a.L[i] = toExportAttribute(e)
+		}
+	}
+	if v.M != nil {
+		a.M = toExportItem(v.M)
+	}
+	return a
+}
+
+func fromExportItem(item map[string]*exportAttribute) map[string]*dynamodb.AttributeValue {
+	out := make(map[string]*dynamodb.AttributeValue, len(item))
+	for k, v := range item {
+		out[k] = fromExportAttribute(v)
+	}
+	return out
+}
+
+func fromExportAttribute(a *exportAttribute) *dynamodb.AttributeValue {
+	if a == nil {
+		return nil
+	}
+	v := &dynamodb.AttributeValue{B: a.B, BOOL: a.BOOL, BS: a.BS, N: a.N, NS: a.NS, NULL: a.NULL, S: a.S, SS: a.SS}
+	if a.L != nil {
+		v.L = make([]*dynamodb.AttributeValue, len(a.L))
+		for i, e := range a.L {
+			v.L[i] = fromExportAttribute(e)
+		}
+	}
+	if a.M != nil {
+		v.M = fromExportItem(a.M)
+	}
+	return v
+}
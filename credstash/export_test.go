@@ -0,0 +1,101 @@
+package credstash
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportFixture(t *testing.T) (*Client, *bytes.Buffer) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+	mustPutSecret(t, c, "beta", "beta-1", 1)
+
+	var buf bytes.Buffer
+	count, err := c.Export("", &buf)
+	assertNoError(t, err)
+	assert.Equal(t, 3, count)
+	return c, &buf
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	c, buf := exportFixture(t)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Contains(t, lines[0], `"format":"credstash-export"`)
+	assert.NotContains(t, buf.String(), "alpha-1")
+
+	result, err := c.Import("restored", buf, ConflictFail)
+
+	assertNoError(t, err)
+	assert.Equal(t, &ImportResult{Imported: 3}, result)
+	secret, err := c.GetHighestVersionSecret("restored", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-2", secret.Secret)
+}
+
+func TestImportConflictModes(t *testing.T) {
+	c, buf := exportFixture(t)
+	archive := buf.String()
+
+	_, err := c.Import("", strings.NewReader(archive), ConflictFail)
+	assert.Error(t, err)
+
+	result, err := c.Import("", strings.NewReader(archive), ConflictSkip)
+	assertNoError(t, err)
+	assert.Equal(t, &ImportResult{Skipped: 3}, result)
+
+	result, err = c.Import("", strings.NewReader(archive), ConflictOverwrite)
+	assertNoError(t, err)
+	assert.Equal(t, &ImportResult{Overwritten: 3}, result)
+
+	_, err = c.Import("", strings.NewReader(archive), ConflictMode("merge"))
+	assert.Error(t, err)
+}
+
+func TestImportFailsOnSoftDeletedVersions(t *testing.T) {
+	c, buf := exportFixture(t)
+	c.opts.SoftDelete = true
+	c.opts.SoftDeleteWindow = 24 * time.Hour
+	assertNoError(t, c.PutSecret("restored", "beta", "deleted", c.PaddedInt(1), NewEncryptionContextValue()))
+	assertNoError(t, c.DeleteSecretVersion("restored", "beta", c.PaddedInt(1)))
+
+	_, err := c.Import("restored", buf, ConflictFail)
+
+	assert.EqualError(t, err, "beta version "+c.PaddedInt(1)+" already exists in table restored")
+	_, err = c.GetLatestVersion("restored", "alpha")
+	assert.True(t, errors.Is(err, ErrSecretNotFound), "nothing is written before the conflict is found")
+}
+
+func TestImportRejectsDamagedArchives(t *testing.T) {
+	c, buf := exportFixture(t)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	truncated := strings.Join(lines[:len(lines)-1], "\n")
+	_, err := c.Import("restored", strings.NewReader(truncated), ConflictFail)
+	assert.Error(t, err)
+
+	dropped := strings.Join(append(append([]string{}, lines[:2]...), lines[3:]...), "\n")
+	_, err = c.Import("restored", strings.NewReader(dropped), ConflictFail)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "item count mismatch")
+
+	swapped := append([]string{}, lines...)
+	swapped[1], swapped[2] = swapped[2], swapped[1]
+	_, err = c.Import("restored", strings.NewReader(strings.Join(swapped, "\n")), ConflictFail)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+	assert.NotContains(t, err.Error(), "count")
+
+	tampered := strings.Replace(buf.String(), `"S":"alpha"`, `"S":"gamma"`, 1)
+	_, err = c.Import("restored", strings.NewReader(tampered), ConflictFail)
+	assert.Error(t, err)
+
+	_, err = c.Import("restored", strings.NewReader("{}\n"), ConflictFail)
+	assert.Error(t, err)
+}
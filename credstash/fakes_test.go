@@ -0,0 +1,22 @@
+package credstash
+
+import (
+	"testing"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash/credstashtest"
+)
+
+func newFakeClient() (*Client, *credstashtest.DynamoDB) {
+	db := credstashtest.NewDynamoDB()
+	return &Client{
+		table:     "credential-store",
+		dynamoDB:  db,
+		decrypter: &credstashtest.KMS{},
+	}, db
+}
+
+func mustPutSecret(t *testing.T, c *Client, name, value string, version int) {
+	t.Helper()
+	err := c.PutSecret("", name, value, c.PaddedInt(version), NewEncryptionContextValue())
+	assertNoError(t, err)
+}
@@ -0,0 +1,226 @@
+package credstash
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/secrethub/secrethub-go/pkg/randchar"
+)
+
+// TemplateClasses maps the character classes usable in generation templates to their characters.
+// The randchar charset names used by GenerateRandomSecret are accepted next to short aliases.
+var TemplateClasses = templateClasses()
+
+func templateClasses() map[string]string {
+	classes := map[string]string{
+		"hex": charsetChars(randchar.Numeric.Add(randchar.NewCharset("abcdef"))),
+		"HEX": charsetChars(randchar.Numeric.Add(randchar.NewCharset("ABCDEF"))),
+	}
+	for _, name := range []string{"alphanumeric", "letters", "numeric", "lowercase", "uppercase", "symbols", "human-readable", "all"} {
+		set, _ := randchar.CharsetByName(name)
+		classes[name] = charsetChars(set)
+	}
+	aliases := map[string]string{
+		"alnum": "alphanumeric",
+		"alpha": "letters",
+		"digit": "numeric",
+		"lower": "lowercase",
+		"upper": "uppercase",
+	}
+	for alias, name := range aliases {
+		classes[alias] = classes[name]
+	}
+	return classes
+}
+
+// ByteEncodings lists the encodings supported by GenerateBytes
+var ByteEncodings = []string{"hex", "base64", "base64url"}
+
+// GenerateUUID returns a random version 4 UUID
+func (c *Client) GenerateUUID(r io.Reader) (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(randReader(r), b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// GenerateBytes returns n random bytes in the given encoding. base64url has no padding so the
+// value can be used in URLs as is.
+func (c *Client) GenerateBytes(n int, encoding string, r io.Reader) (string, error) {
+	if n < 1 {
+		return "", fmt.Errorf("the number of bytes must be positive, got %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(randReader(r), b); err != nil {
+		return "", err
+	}
+	switch encoding {
+	case "hex":
+		return hex.EncodeToString(b), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(b), nil
+	case "base64url":
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	}
+	return "", fmt.Errorf("unknown encoding %q, expected one of %s", encoding, strings.Join(ByteEncodings, ", "))
+}
+
+// templateSegment is literal text, or a number of random characters from a class
+type templateSegment struct {
+	literal string
+	chars   string
+	count   int
+}
+
+// ParseTemplate checks a generation template like `sk_live_{alnum:24}`. Text outside braces is
+// kept as is, `{class:n}` is replaced by n random characters of the class, and `{{` and `}}`
+// stand for literal braces.
+func ParseTemplate(template string) error {
+	_, err := parseTemplate(template)
+	return err
+}
+
+func parseTemplate(template string) ([]templateSegment, error) {
+	var segments []templateSegment
+	var literal strings.Builder
+	random := 0
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "{{"), strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte(template[i])
+			i++
+		case template[i] == '}':
+			return nil, fmt.Errorf("template %q: unexpected } at offset %d, use }} for a literal brace", template, i)
+		case template[i] == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("template %q: unclosed { at offset %d", template, i)
+			}
+			segment, err := parsePlaceholder(template[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("template %q: %w", template, err)
+			}
+			if literal.Len() > 0 {
+				segments = append(segments, templateSegment{literal: literal.String()})
+				literal.Reset()
+			}
+			segments = append(segments, segment)
+			random += segment.count
+			i += end
+		default:
+			literal.WriteByte(template[i])
+		}
+	}
+	if literal.Len() > 0 {
+		segments = append(segments, templateSegment{literal: literal.String()})
+	}
+	if random == 0 {
+		return nil, fmt.Errorf("template %q has no {class:n} placeholder, it would always generate the same value", template)
+	}
+	return segments, nil
+}
+
+func parsePlaceholder(placeholder string) (templateSegment, error) {
+	parts := strings.SplitN(placeholder, ":", 2)
+	if len(parts) != 2 {
+		return templateSegment{}, fmt.Errorf("placeholder {%s} must look like {class:n}", placeholder)
+	}
+	chars, ok := TemplateClasses[parts[0]]
+	if !ok {
+		return templateSegment{}, fmt.Errorf("unknown class %q in {%s}, expected one of %s", parts[0], placeholder, strings.Join(templateClassNames(), ", "))
+	}
+	count, err := strconv.Atoi(parts[1])
+	if err != nil || count < 1 {
+		return templateSegment{}, fmt.Errorf("length %q in {%s} must be a positive number", parts[1], placeholder)
+	}
+	return templateSegment{chars: chars, count: count}, nil
+}
+
+// GenerateFromTemplate fills the placeholders of a template parsed by ParseTemplate
+func (c *Client) GenerateFromTemplate(template string, r io.Reader) (string, error) {
+	segments, err := parseTemplate(template)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, segment := range segments {
+		if segment.count == 0 {
+			b.WriteString(segment.literal)
+			continue
+		}
+		value, err := randomChars(randReader(r), segment.chars, segment.count)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+// TemplateEntropyBits returns the entropy of values generated from a template, the sum of the
+// entropy of its placeholders
+func TemplateEntropyBits(template string) (float64, error) {
+	segments, err := parseTemplate(template)
+	if err != nil {
+		return 0, err
+	}
+	bits := 0.0
+	for _, segment := range segments {
+		if segment.count > 0 {
+			bits += float64(segment.count) * math.Log2(float64(len(segment.chars)))
+		}
+	}
+	return bits, nil
+}
+
+// randomChars picks n characters uniformly from chars
+func randomChars(r io.Reader, chars string, n int) (string, error) {
+	size := big.NewInt(int64(len(chars)))
+	b := make([]byte, n)
+	for i := range b {
+		index, err := rand.Int(r, size)
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[index.Int64()]
+	}
+	return string(b), nil
+}
+
+// removeChars returns chars without any of the characters in remove
+func removeChars(chars string, remove string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(remove, r) {
+			return -1
+		}
+		return r
+	}, chars)
+}
+
+// randReader returns r, or crypto/rand when r is nil
+func randReader(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
+
+func templateClassNames() []string {
+	names := make([]string, 0, len(TemplateClasses))
+	for name := range TemplateClasses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
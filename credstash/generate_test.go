@@ -0,0 +1,80 @@
+package credstash
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateUUID(t *testing.T) {
+	c, _ := newFakeClient()
+
+	value, err := c.GenerateUUID(nil)
+
+	assertNoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), value)
+}
+
+func TestGenerateBytes(t *testing.T) {
+	c, _ := newFakeClient()
+	zeros := func() *bytes.Reader { return bytes.NewReader(make([]byte, 64)) }
+
+	value, err := c.GenerateBytes(4, "hex", zeros())
+	assertNoError(t, err)
+	assert.Equal(t, "00000000", value)
+
+	value, err = c.GenerateBytes(4, "base64", zeros())
+	assertNoError(t, err)
+	assert.Equal(t, "AAAAAA==", value)
+
+	value, err = c.GenerateBytes(4, "base64url", zeros())
+	assertNoError(t, err)
+	assert.Equal(t, "AAAAAA", value)
+
+	_, err = c.GenerateBytes(4, "base32", zeros())
+	assertError(t, err)
+	_, err = c.GenerateBytes(0, "hex", zeros())
+	assertError(t, err)
+}
+
+func TestGenerateFromTemplate(t *testing.T) {
+	c, _ := newFakeClient()
+
+	value, err := c.GenerateFromTemplate("sk_live_{alnum:24}", nil)
+	assertNoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^sk_live_[A-Za-z0-9]{24}$`), value)
+
+	value, err = c.GenerateFromTemplate("{{{digit:4}}}-{HEX:2}", nil)
+	assertNoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^\{[0-9]{4}\}-[0-9A-F]{2}$`), value)
+}
+
+func TestTemplateClassesMatchCharsets(t *testing.T) {
+	assert.Equal(t, "!#$%*+,-.=?@^_", TemplateClasses["symbols"])
+	assert.Equal(t, TemplateClasses["alphanumeric"], TemplateClasses["alnum"])
+	assert.Len(t, TemplateClasses["all"], 62+14)
+	assert.NotContains(t, TemplateClasses["human-readable"], "l")
+	assert.Len(t, TemplateClasses["human-readable"], 62-8)
+}
+
+func TestTemplateEntropyBits(t *testing.T) {
+	bits, err := TemplateEntropyBits("sk_{hex:4}_{digit:2}")
+	assertNoError(t, err)
+	assert.InDelta(t, 16+2*3.32, bits, 0.01)
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	for _, template := range []string{
+		"static",
+		"sk_{alnum:24",
+		"sk_{alnum}",
+		"sk_{klingon:4}",
+		"sk_{alnum:0}",
+		"sk_}{alnum:4}",
+	} {
+		assert.Error(t, ParseTemplate(template), template)
+	}
+	assert.NoError(t, ParseTemplate("{human-readable:8}"))
+}
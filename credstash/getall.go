@@ -0,0 +1,42 @@
+package credstash
+
+import (
+	"log"
+	"strings"
+)
+
+// GetAllSecrets decrypts the latest version of every secret whose name starts with prefix and
+// returns the values keyed by name, like `credstash getall`. The table is scanned once and the
+// values are read in bulk.
+func (c *Client) GetAllSecrets(tableName string, prefix string, ctx *EncryptionContextValue) (map[string]string, error) {
+	log.Printf("Getting all secrets with prefix: %q", prefix)
+
+	creds, err := c.ListSecrets(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The listing is sorted by name and version, so the last entry per name is the latest
+	var refs []SecretRef
+	for _, cred := range creds {
+		if !strings.HasPrefix(cred.Name, prefix) {
+			continue
+		}
+		if len(refs) > 0 && refs[len(refs)-1].Name == cred.Name {
+			refs[len(refs)-1].Version = cred.Version
+			continue
+		}
+		refs = append(refs, SecretRef{Table: tableName, Name: cred.Name, Version: cred.Version, Context: ctx})
+	}
+
+	decrypted, err := c.GetSecrets(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string, len(decrypted))
+	for _, secret := range decrypted {
+		secrets[secret.Name] = secret.Secret
+	}
+	return secrets, nil
+}
@@ -0,0 +1,33 @@
+package credstash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAllSecretsLatestVersions(t *testing.T) {
+	c, db := newFakeClient()
+	db.ScanPageSize = 2
+	mustPutSecret(t, c, "app.alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "app.alpha", "alpha-2", 2)
+	mustPutSecret(t, c, "app.beta", "beta-1", 1)
+	mustPutSecret(t, c, "other", "other-1", 1)
+
+	secrets, err := c.GetAllSecrets("", "", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, map[string]string{"app.alpha": "alpha-2", "app.beta": "beta-1", "other": "other-1"}, secrets)
+
+	secrets, err = c.GetAllSecrets("", "app.", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, map[string]string{"app.alpha": "alpha-2", "app.beta": "beta-1"}, secrets)
+}
+
+func TestGetAllSecretsEmpty(t *testing.T) {
+	c, _ := newFakeClient()
+
+	secrets, err := c.GetAllSecrets("", "missing.", NewEncryptionContextValue())
+
+	assertNoError(t, err)
+	assert.Empty(t, secrets)
+}
@@ -0,0 +1,169 @@
+package credstash
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	KeyAlgorithmRSA     = "RSA"
+	KeyAlgorithmECDSA   = "ECDSA"
+	KeyAlgorithmEd25519 = "ED25519"
+)
+
+// KeyAlgorithms lists the algorithms supported by GenerateKeyPair
+var KeyAlgorithms = []string{KeyAlgorithmRSA, KeyAlgorithmECDSA, KeyAlgorithmEd25519}
+
+// ECDSACurves maps the supported curve names to their curves
+var ECDSACurves = map[string]elliptic.Curve{
+	"P256": elliptic.P256(),
+	"P384": elliptic.P384(),
+	"P521": elliptic.P521(),
+}
+
+// KeyPairOptions holds the settings of GenerateKeyPair
+type KeyPairOptions struct {
+	// Algorithm is one of KeyAlgorithms
+	Algorithm string
+	// RSABits is the size of RSA keys
+	RSABits int
+	// ECDSACurve is one of ECDSACurves
+	ECDSACurve string
+	// Rand is the source of randomness, crypto/rand when nil
+	Rand io.Reader
+}
+
+// PublicKeyInfo describes the public half of a key pair
+type PublicKeyInfo struct {
+	// PEM is the PKIX public key
+	PEM string
+	// OpenSSH is the key in authorized_keys format
+	OpenSSH string
+	// FingerprintSHA256 is the OpenSSH SHA256 fingerprint, like `SHA256:...`
+	FingerprintSHA256 string
+	// FingerprintMD5 is the legacy colon separated OpenSSH MD5 fingerprint
+	FingerprintMD5 string
+	// Algorithm is one of KeyAlgorithms
+	Algorithm string
+	// RSABits is the size of RSA keys, 0 for other algorithms
+	RSABits int
+	// ECDSACurve is the ECDSACurves name of ECDSA keys, empty for other algorithms
+	ECDSACurve string
+}
+
+// GenerateKeyPair generates a private key and returns it PEM encoded: PKCS#1 for RSA, SEC 1
+// for ECDSA and PKCS#8 for Ed25519, the formats OpenSSH and most TLS servers load.
+func (c *Client) GenerateKeyPair(opts KeyPairOptions) (string, error) {
+	random := randReader(opts.Rand)
+
+	var block *pem.Block
+	switch opts.Algorithm {
+	case KeyAlgorithmRSA:
+		if opts.RSABits < 2048 {
+			return "", fmt.Errorf("RSA keys need at least 2048 bits, got %d", opts.RSABits)
+		}
+		key, err := rsa.GenerateKey(random, opts.RSABits)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case KeyAlgorithmECDSA:
+		curve, ok := ECDSACurves[opts.ECDSACurve]
+		if !ok {
+			return "", fmt.Errorf("unknown ECDSA curve %q, expected P256, P384 or P521", opts.ECDSACurve)
+		}
+		key, err := ecdsa.GenerateKey(curve, random)
+		if err != nil {
+			return "", err
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	case KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(random)
+		if err != nil {
+			return "", err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		return "", fmt.Errorf("unknown key algorithm %q, expected one of %s", opts.Algorithm, strings.Join(KeyAlgorithms, ", "))
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// PublicKey derives the public key formats from a PEM private key written by GenerateKeyPair
+func PublicKey(privateKeyPEM string) (*PublicKeyInfo, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, errors.New("no PEM private key found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	sshKey, err := ssh.NewPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	info := &PublicKeyInfo{
+		PEM:               string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		OpenSSH:           string(ssh.MarshalAuthorizedKey(sshKey)),
+		FingerprintSHA256: ssh.FingerprintSHA256(sshKey),
+		FingerprintMD5:    ssh.FingerprintLegacyMD5(sshKey),
+	}
+	switch public := signer.Public().(type) {
+	case *rsa.PublicKey:
+		info.Algorithm = KeyAlgorithmRSA
+		info.RSABits = public.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.Algorithm = KeyAlgorithmECDSA
+		for name, curve := range ECDSACurves {
+			if curve == public.Curve {
+				info.ECDSACurve = name
+			}
+		}
+	case ed25519.PublicKey:
+		info.Algorithm = KeyAlgorithmEd25519
+	}
+	return info, nil
+}
@@ -0,0 +1,49 @@
+package credstash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	c, _ := newFakeClient()
+	cases := []struct {
+		opts    KeyPairOptions
+		pemType string
+		sshType string
+	}{
+		{opts: KeyPairOptions{Algorithm: KeyAlgorithmRSA, RSABits: 2048}, pemType: "RSA PRIVATE KEY", sshType: "ssh-rsa "},
+		{opts: KeyPairOptions{Algorithm: KeyAlgorithmECDSA, ECDSACurve: "P256"}, pemType: "EC PRIVATE KEY", sshType: "ecdsa-sha2-nistp256 "},
+		{opts: KeyPairOptions{Algorithm: KeyAlgorithmEd25519}, pemType: "PRIVATE KEY", sshType: "ssh-ed25519 "},
+	}
+	for _, tc := range cases {
+		private, err := c.GenerateKeyPair(tc.opts)
+		assertNoError(t, err)
+		assert.True(t, strings.HasPrefix(private, "-----BEGIN "+tc.pemType+"-----"), tc.opts.Algorithm)
+
+		public, err := PublicKey(private)
+		assertNoError(t, err)
+		assert.True(t, strings.HasPrefix(public.PEM, "-----BEGIN PUBLIC KEY-----"), tc.opts.Algorithm)
+		assert.True(t, strings.HasPrefix(public.OpenSSH, tc.sshType), tc.opts.Algorithm)
+		assert.True(t, strings.HasPrefix(public.FingerprintSHA256, "SHA256:"), tc.opts.Algorithm)
+		assert.Len(t, strings.Split(public.FingerprintMD5, ":"), 16)
+		assert.Equal(t, tc.opts.Algorithm, public.Algorithm)
+		assert.Equal(t, tc.opts.RSABits, public.RSABits)
+		assert.Equal(t, tc.opts.ECDSACurve, public.ECDSACurve)
+	}
+}
+
+func TestGenerateKeyPairInvalidOptions(t *testing.T) {
+	c, _ := newFakeClient()
+
+	_, err := c.GenerateKeyPair(KeyPairOptions{Algorithm: KeyAlgorithmRSA, RSABits: 1024})
+	assertError(t, err)
+	_, err = c.GenerateKeyPair(KeyPairOptions{Algorithm: KeyAlgorithmECDSA, ECDSACurve: "P224"})
+	assertError(t, err)
+	_, err = c.GenerateKeyPair(KeyPairOptions{Algorithm: "DSA"})
+	assertError(t, err)
+	_, err = PublicKey("not a key")
+	assertError(t, err)
+}
@@ -0,0 +1,60 @@
+package credstash
+
+import (
+	"errors"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// UpdateMetadata replaces the metadata of one version of a secret in place, without writing a
+// new version. Empty metadata removes the attribute.
+func (c *Client) UpdateMetadata(tableName string, name string, paddedVersion string, metadata map[string]string) error {
+	log.Printf("Updating metadata: %s", name)
+
+	var value *dynamodb.AttributeValue
+	if len(metadata) > 0 {
+		var err error
+		value, err = dynamodbattribute.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+	}
+	return c.updateAttribute(tableName, name, paddedVersion, "metadata", value)
+}
+
+// updateAttribute sets an attribute of an existing item, or removes it when value is nil
+func (c *Client) updateAttribute(tableName string, name string, paddedVersion string, attribute string, value *dynamodb.AttributeValue) error {
+	if tableName == "" {
+		tableName = c.table
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: &tableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			"name":    {S: aws.String(name)},
+			"version": {S: aws.String(paddedVersion)},
+		},
+		ExpressionAttributeNames: map[string]*string{
+			"#N": aws.String("name"),
+			"#A": aws.String(attribute),
+		},
+		// Never create an item holding only the attribute
+		ConditionExpression: aws.String("attribute_exists(#N)"),
+		UpdateExpression:    aws.String("REMOVE #A"),
+	}
+	if value != nil {
+		input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{":v": value}
+		input.UpdateExpression = aws.String("SET #A = :v")
+	}
+
+	_, err := c.dynamoDB.UpdateItem(input)
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrSecretNotFound
+	}
+	return err
+}
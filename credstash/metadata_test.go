@@ -0,0 +1,41 @@
+package credstash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutSecretWithMetadata(t *testing.T) {
+	c, _ := newFakeClient()
+	metadata := map[string]string{"owner": "platform", "ticket": "SEC-42"}
+
+	err := c.PutSecretWithOptions("", "app.db", "hunter2", c.PaddedInt(1), NewEncryptionContextValue(), PutOptions{Metadata: metadata})
+	assertNoError(t, err)
+
+	secret, err := c.GetHighestVersionSecret("", "app.db", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, metadata, secret.Metadata)
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "app.db", "hunter2", 1)
+
+	err := c.UpdateMetadata("", "app.db", c.PaddedInt(1), map[string]string{"owner": "platform"})
+	assertNoError(t, err)
+	secret, err := c.GetSecret("app.db", "", c.PaddedInt(1), NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "platform"}, secret.Metadata)
+	assert.Equal(t, "hunter2", secret.Secret)
+
+	err = c.UpdateMetadata("", "app.db", c.PaddedInt(1), nil)
+	assertNoError(t, err)
+	secret, err = c.GetSecret("app.db", "", c.PaddedInt(1), NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Empty(t, secret.Metadata)
+
+	err = c.UpdateMetadata("", "app.db", c.PaddedInt(2), map[string]string{"owner": "platform"})
+	assert.True(t, errors.Is(err, ErrSecretNotFound), err)
+}
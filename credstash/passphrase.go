@@ -0,0 +1,144 @@
+package credstash
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed wordlists/*.txt
+var wordlistFiles embed.FS
+
+// Wordlists maps the supported wordlist names to their embedded files
+var Wordlists = map[string]string{
+	"eff_large": "wordlists/eff_large_wordlist.txt",
+	"eff_short": "wordlists/eff_short_wordlist_2_0.txt",
+}
+
+// DefaultWordlist is the wordlist used when none is given, 12.9 bits of entropy per word
+const DefaultWordlist = "eff_large"
+
+var (
+	wordlistsMu     sync.Mutex
+	wordlistsLoaded = map[string][]string{}
+)
+
+// PassphraseOptions holds the settings of GeneratePassphrase
+type PassphraseOptions struct {
+	// Words is the number of words in the passphrase
+	Words int
+	// Separator is written between the words
+	Separator string
+	// Wordlist is one of Wordlists, DefaultWordlist when empty
+	Wordlist string
+	// Capitalize upper cases the first letter of every word
+	Capitalize bool
+	// IncludeNumber appends a random digit to one random word
+	IncludeNumber bool
+	// Rand is the source of randomness, crypto/rand when nil
+	Rand io.Reader
+}
+
+// GeneratePassphrase picks words uniformly from a diceware wordlist
+func (c *Client) GeneratePassphrase(opts PassphraseOptions) (string, error) {
+	if opts.Words < 1 {
+		return "", fmt.Errorf("a passphrase needs at least 1 word, got %d", opts.Words)
+	}
+	words, err := loadWordlist(opts.Wordlist)
+	if err != nil {
+		return "", err
+	}
+	random := randReader(opts.Rand)
+
+	picked := make([]string, opts.Words)
+	for i := range picked {
+		n, err := rand.Int(random, big.NewInt(int64(len(words))))
+		if err != nil {
+			return "", err
+		}
+		picked[i] = words[n.Int64()]
+		if opts.Capitalize {
+			picked[i] = strings.ToUpper(picked[i][:1]) + picked[i][1:]
+		}
+	}
+
+	if opts.IncludeNumber {
+		word, err := rand.Int(random, big.NewInt(int64(len(picked))))
+		if err != nil {
+			return "", err
+		}
+		digit, err := rand.Int(random, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		picked[word.Int64()] += digit.String()
+	}
+
+	return strings.Join(picked, opts.Separator), nil
+}
+
+// PassphraseEntropyBits returns the entropy of a passphrase generated with the options. The
+// random digit of IncludeNumber adds the bits of the digit and of the word it is appended to.
+func PassphraseEntropyBits(opts PassphraseOptions) (float64, error) {
+	if opts.Words < 1 {
+		return 0, fmt.Errorf("a passphrase needs at least 1 word, got %d", opts.Words)
+	}
+	words, err := loadWordlist(opts.Wordlist)
+	if err != nil {
+		return 0, err
+	}
+	bits := float64(opts.Words) * math.Log2(float64(len(words)))
+	if opts.IncludeNumber {
+		bits += math.Log2(10) + math.Log2(float64(opts.Words))
+	}
+	return bits, nil
+}
+
+// loadWordlist parses an embedded wordlist once, every line is a dice roll and a word
+func loadWordlist(name string) ([]string, error) {
+	if name == "" {
+		name = DefaultWordlist
+	}
+	file, ok := Wordlists[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown wordlist %q, expected one of %s", name, strings.Join(wordlistNames(), ", "))
+	}
+
+	wordlistsMu.Lock()
+	defer wordlistsMu.Unlock()
+	if words, ok := wordlistsLoaded[name]; ok {
+		return words, nil
+	}
+
+	data, err := wordlistFiles.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var words []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			words = append(words, fields[1])
+		}
+	}
+	wordlistsLoaded[name] = words
+	return words, nil
+}
+
+func wordlistNames() []string {
+	names := make([]string, 0, len(Wordlists))
+	for name := range Wordlists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,56 @@
+package credstash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWordlists(t *testing.T) {
+	words, err := loadWordlist("")
+	assertNoError(t, err)
+	assert.Len(t, words, 7776)
+	assert.Equal(t, "abacus", words[0])
+
+	words, err = loadWordlist("eff_short")
+	assertNoError(t, err)
+	assert.Len(t, words, 1296)
+
+	_, err = loadWordlist("klingon")
+	assertError(t, err)
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	c, _ := newFakeClient()
+
+	value, err := c.GeneratePassphrase(PassphraseOptions{Words: 6, Separator: "-"})
+	assertNoError(t, err)
+	assert.Len(t, strings.Split(value, "-"), 6)
+
+	value, err = c.GeneratePassphrase(PassphraseOptions{Words: 4, Separator: " ", Capitalize: true, IncludeNumber: true})
+	assertNoError(t, err)
+	words := strings.Split(value, " ")
+	assert.Len(t, words, 4)
+	digits := 0
+	for _, word := range words {
+		assert.Equal(t, strings.ToUpper(word[:1]), word[:1])
+		if strings.ContainsAny(word, "0123456789") {
+			digits++
+		}
+	}
+	assert.Equal(t, 1, digits)
+
+	_, err = c.GeneratePassphrase(PassphraseOptions{Words: 0})
+	assertError(t, err)
+}
+
+func TestPassphraseEntropyBits(t *testing.T) {
+	bits, err := PassphraseEntropyBits(PassphraseOptions{Words: 6})
+	assertNoError(t, err)
+	assert.InDelta(t, 77.55, bits, 0.01)
+
+	bits, err = PassphraseEntropyBits(PassphraseOptions{Words: 4, Wordlist: "eff_short", IncludeNumber: true})
+	assertNoError(t, err)
+	assert.InDelta(t, 4*10.34+3.32+2, bits, 0.01)
+}
@@ -0,0 +1,188 @@
+package credstash
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/secrethub/secrethub-go/pkg/randchar"
+)
+
+// ambiguousChars are left out of passwords with NoAmbiguous
+const ambiguousChars = "0O1lI"
+
+// PasswordOptions holds the settings of GeneratePassword
+type PasswordOptions struct {
+	// Length is the number of characters
+	Length int
+	// UseSymbols adds the randchar symbols charset
+	UseSymbols bool
+	// Charsets are randchar charset names, alphanumeric when empty
+	Charsets []string
+	// Min maps randchar charset names to the minimum number of characters of that charset
+	Min map[string]int
+	// CustomCharset replaces Charsets and UseSymbols with the given characters
+	CustomCharset string
+	// ExcludeChars are removed from the charset and from the charsets of Min
+	ExcludeChars string
+	// NoAmbiguous removes characters that are easily mistaken for each other: 0O1lI
+	NoAmbiguous bool
+	// Rand is the source of randomness, crypto/rand when nil
+	Rand io.Reader
+}
+
+// passwordMinimum is a number of characters that must be picked from chars
+type passwordMinimum struct {
+	chars string
+	count int
+}
+
+// GeneratePassword picks characters uniformly from the charset of the options, making sure the
+// minimums are met. Given the same Rand it always returns the same password.
+func (c *Client) GeneratePassword(opts PasswordOptions) (string, error) {
+	chars, minimums, err := opts.charset()
+	if err != nil {
+		return "", err
+	}
+	required := 0
+	for _, min := range minimums {
+		required += min.count
+	}
+	if opts.Length < required {
+		return "", fmt.Errorf("length %d is shorter than the %d characters required by min", opts.Length, required)
+	}
+	random := randReader(opts.Rand)
+
+	var b strings.Builder
+	for _, min := range minimums {
+		value, err := randomChars(random, min.chars, min.count)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	value, err := randomChars(random, chars, opts.Length-required)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(value)
+	if required == 0 {
+		return b.String(), nil
+	}
+
+	// Spread the required characters, as they were picked first
+	password := []byte(b.String())
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(random, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+	return string(password), nil
+}
+
+// PasswordEntropyBits returns the entropy of a password generated with the options: the length
+// times the log2 of the charset size. Minimums make it slightly lower in practice.
+func PasswordEntropyBits(opts PasswordOptions) (float64, error) {
+	chars, _, err := opts.charset()
+	if err != nil {
+		return 0, err
+	}
+	return float64(opts.Length) * math.Log2(float64(len(chars))), nil
+}
+
+// charset returns the sorted characters passwords are picked from, and the minimums sorted by
+// charset name. The charsets of the minimums are limited to the characters left in the charset.
+func (opts PasswordOptions) charset() (string, []passwordMinimum, error) {
+	remove := opts.ExcludeChars
+	if opts.NoAmbiguous {
+		remove += ambiguousChars
+	}
+
+	var chars string
+	if opts.CustomCharset != "" {
+		for _, r := range opts.CustomCharset {
+			if r >= utf8.RuneSelf || r < ' ' || r == 0x7f {
+				return "", nil, fmt.Errorf("the custom charset must only contain printable ASCII characters")
+			}
+		}
+		chars = opts.CustomCharset
+	} else {
+		names := opts.Charsets
+		if len(names) == 0 {
+			names = []string{"alphanumeric"}
+		}
+		if opts.UseSymbols {
+			names = append(names[:len(names):len(names)], "symbols")
+		}
+		for _, name := range names {
+			set, found := randchar.CharsetByName(name)
+			if !found {
+				return "", nil, fmt.Errorf("unknown charset: %s", name)
+			}
+			chars += charsetChars(set)
+		}
+	}
+	chars = uniqueSortedChars(removeChars(chars, remove))
+	if chars == "" {
+		return "", nil, fmt.Errorf("no characters are left to generate a password from")
+	}
+
+	minNames := make([]string, 0, len(opts.Min))
+	for name := range opts.Min {
+		minNames = append(minNames, name)
+	}
+	sort.Strings(minNames)
+	var minimums []passwordMinimum
+	for _, name := range minNames {
+		set, found := randchar.CharsetByName(name)
+		if !found {
+			return "", nil, fmt.Errorf("unknown charset: %s", name)
+		}
+		if opts.Min[name] < 1 {
+			return "", nil, fmt.Errorf("minimum of %s must be at least 1", name)
+		}
+		minChars := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(chars, r) {
+				return r
+			}
+			return -1
+		}, charsetChars(set))
+		if minChars == "" {
+			return "", nil, fmt.Errorf("no %s characters are left to meet the minimum", name)
+		}
+		minimums = append(minimums, passwordMinimum{chars: minChars, count: opts.Min[name]})
+	}
+	return chars, minimums, nil
+}
+
+// charsetChars lists the characters of a randchar charset in byte order
+func charsetChars(set randchar.Charset) string {
+	var b strings.Builder
+	for r := rune(0); r < utf8.RuneSelf; r++ {
+		if randchar.NewCharset(string(r)).IsSubset(set) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// uniqueSortedChars sorts the bytes of chars and drops duplicates, so every character is as
+// likely to be picked
+func uniqueSortedChars(chars string) string {
+	b := []byte(chars)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	unique := b[:0]
+	for _, char := range b {
+		if len(unique) == 0 || char != unique[len(unique)-1] {
+			unique = append(unique, char)
+		}
+	}
+	return string(unique)
+}
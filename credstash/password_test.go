@@ -0,0 +1,71 @@
+package credstash
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	c, _ := newFakeClient()
+
+	value, err := c.GeneratePassword(PasswordOptions{Length: 64, UseSymbols: true, ExcludeChars: `"\'` + "`", NoAmbiguous: true})
+	assertNoError(t, err)
+	assert.Len(t, value, 64)
+	assert.False(t, strings.ContainsAny(value, `"\'`+"`"+ambiguousChars), value)
+
+	value, err = c.GeneratePassword(PasswordOptions{Length: 32, CustomCharset: "abc123", Min: map[string]int{"numeric": 4}})
+	assertNoError(t, err)
+	assert.Len(t, value, 32)
+	assert.Equal(t, "", strings.Trim(value, "abc123"))
+	digits := 0
+	for _, r := range value {
+		if strings.ContainsRune("123", r) {
+			digits++
+		}
+	}
+	assert.GreaterOrEqual(t, digits, 4)
+}
+
+func TestGeneratePasswordIsDeterministicGivenTheReader(t *testing.T) {
+	c, _ := newFakeClient()
+	seed := bytes.Repeat([]byte{7, 42, 199, 3}, 256)
+	opts := PasswordOptions{Length: 24, UseSymbols: true, Min: map[string]int{"uppercase": 2, "numeric": 2}}
+
+	opts.Rand = bytes.NewReader(seed)
+	first, err := c.GeneratePassword(opts)
+	assertNoError(t, err)
+	opts.Rand = bytes.NewReader(seed)
+	second, err := c.GeneratePassword(opts)
+	assertNoError(t, err)
+
+	assert.Equal(t, first, second)
+}
+
+func TestGeneratePasswordErrors(t *testing.T) {
+	c, _ := newFakeClient()
+
+	for name, opts := range map[string]PasswordOptions{
+		"unknown charset":    {Length: 8, Charsets: []string{"klingon"}},
+		"everything removed": {Length: 8, Charsets: []string{"numeric"}, ExcludeChars: "0123456789"},
+		"min not in charset": {Length: 8, CustomCharset: "abc", Min: map[string]int{"numeric": 1}},
+		"min over length":    {Length: 2, Min: map[string]int{"numeric": 3}},
+		"non ASCII charset":  {Length: 8, CustomCharset: "abcé"},
+	} {
+		_, err := c.GeneratePassword(opts)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestPasswordEntropyBits(t *testing.T) {
+	bits, err := PasswordEntropyBits(PasswordOptions{Length: 10, Charsets: []string{"numeric"}})
+	assertNoError(t, err)
+	assert.InDelta(t, 33.22, bits, 0.01)
+
+	// 62 alphanumeric characters minus 0O1lI
+	bits, err = PasswordEntropyBits(PasswordOptions{Length: 10, NoAmbiguous: true})
+	assertNoError(t, err)
+	assert.InDelta(t, 58.33, bits, 0.01)
+}
@@ -0,0 +1,129 @@
+package credstash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/secrethub/secrethub-go/pkg/randchar"
+)
+
+// Policy describes the requirements a supplied secret value must meet. Zero fields are not checked.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// Require maps randchar charset names, as used by GenerateRandomSecret, to the minimum
+	// number of characters of that charset
+	Require map[string]int
+	// ForbidChars lists characters the value must not contain
+	ForbidChars string
+	// MinEntropyBits is checked against EstimateEntropyBits
+	MinEntropyBits float64
+}
+
+// Validate checks the policy itself, like the charset names in Require
+func (p Policy) Validate() error {
+	if p.MaxLength > 0 && p.MinLength > p.MaxLength {
+		return fmt.Errorf("min_length %d is greater than max_length %d", p.MinLength, p.MaxLength)
+	}
+	for _, name := range p.requireNames() {
+		if _, found := randchar.CharsetByName(name); !found {
+			return fmt.Errorf("unknown charset: %s", name)
+		}
+	}
+	return nil
+}
+
+// Check returns an error describing every requirement the value does not meet. The errors
+// never include the value or any of its characters, so they are safe to show in a plan.
+func (p Policy) Check(value string) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	var problems []string
+	length := len([]rune(value))
+	if p.MinLength > 0 && length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("is shorter than %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("is longer than %d characters", p.MaxLength))
+	}
+	for _, name := range p.requireNames() {
+		set, _ := randchar.CharsetByName(name)
+		if n := countInCharset(value, set); n < p.Require[name] {
+			problems = append(problems, fmt.Sprintf("has fewer than %d %s characters", p.Require[name], name))
+		}
+	}
+	if p.ForbidChars != "" && strings.ContainsAny(value, p.ForbidChars) {
+		problems = append(problems, "contains a forbidden character")
+	}
+	if p.MinEntropyBits > 0 && EstimateEntropyBits(value) < p.MinEntropyBits {
+		problems = append(problems, fmt.Sprintf("has an estimated entropy below %.0f bits", p.MinEntropyBits))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("value does not meet the policy: it %s", strings.Join(problems, ", it "))
+	}
+	return nil
+}
+
+func (p Policy) requireNames() []string {
+	names := make([]string, 0, len(p.Require))
+	for name := range p.Require {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func countInCharset(value string, set randchar.Charset) int {
+	n := 0
+	for _, r := range value {
+		if r < utf8.RuneSelf && randchar.NewCharset(string(r)).IsSubset(set) {
+			n++
+		}
+	}
+	return n
+}
+
+// EstimateEntropyBits estimates the strength of a supplied value as its length times the
+// log2 of the pool of the character classes it uses: lowercase, uppercase, digits, ASCII
+// punctuation and anything else. It is an upper bound, dictionary words score far lower
+// in practice.
+func EstimateEntropyBits(value string) float64 {
+	var lower, upper, digit, punct, other bool
+	length := 0
+	for _, r := range value {
+		length++
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < utf8.RuneSelf && unicode.IsPrint(r):
+			punct = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {punct, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(length) * math.Log2(float64(pool))
+}
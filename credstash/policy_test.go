@@ -0,0 +1,40 @@
+package credstash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		MinLength:      12,
+		MaxLength:      64,
+		Require:        map[string]int{"uppercase": 1, "numeric": 2},
+		ForbidChars:    `"'\`,
+		MinEntropyBits: 60,
+	}
+
+	assertNoError(t, policy.Check("Correct-Horse-42-Battery"))
+
+	err := policy.Check("short'1")
+	assertError(t, err)
+	assert.Contains(t, err.Error(), "shorter than 12 characters")
+	assert.Contains(t, err.Error(), "fewer than 1 uppercase characters")
+	assert.Contains(t, err.Error(), "fewer than 2 numeric characters")
+	assert.Contains(t, err.Error(), "forbidden character")
+	assert.Contains(t, err.Error(), "entropy below 60 bits")
+	assert.NotContains(t, err.Error(), "short'1")
+}
+
+func TestPolicyValidate(t *testing.T) {
+	assertError(t, Policy{Require: map[string]int{"klingon": 1}}.Validate())
+	assertError(t, Policy{MinLength: 10, MaxLength: 8}.Validate())
+	assertNoError(t, Policy{Require: map[string]int{"human-readable": 1}}.Validate())
+}
+
+func TestEstimateEntropyBits(t *testing.T) {
+	assert.Equal(t, 0.0, EstimateEntropyBits(""))
+	assert.InDelta(t, 8*4.7, EstimateEntropyBits("abcdefgh"), 0.1)
+	assert.InDelta(t, 8*5.95, EstimateEntropyBits("abcdEF12"), 0.1)
+}
@@ -0,0 +1,159 @@
+package credstash
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ReencryptOptions holds the settings of Reencrypt
+type ReencryptOptions struct {
+	// KmsKey wraps the new versions, DefaultKmsKey when empty
+	KmsKey string
+	// Context is the encryption context of the new versions
+	Context *EncryptionContextValue
+	// AllVersions rewrites every live version instead of only the latest one
+	AllVersions bool
+	// UpTo is the highest version rewritten, so a resumed run leaves the copies written by the
+	// interrupted one alone. Empty rewrites up to the latest version.
+	UpTo string
+	// Skip reports source versions an earlier run already rewrote, nil skips none
+	Skip func(version string) bool
+	// Done is called with each source version once its copy is written, to record progress
+	Done func(version string) error
+}
+
+// Reencrypt decrypts the latest version of a secret, or every version with AllVersions, and
+// writes the values again as new versions wrapped under the options' key and context. Versions
+// are rewritten oldest first so their order is kept. The old versions are left in place and
+// still need the previous key to be read. It returns the versions written.
+func (c *Client) Reencrypt(tableName string, name string, ctx *EncryptionContextValue, opts ReencryptOptions) ([]string, error) {
+	tableName = c.TableName(tableName)
+	log.Printf("Re-encrypting secret: %s", name)
+
+	newKey := opts.KmsKey
+	if newKey == "" {
+		newKey = DefaultKmsKey
+	}
+
+	var creds []*Credential
+	if opts.AllVersions || opts.UpTo != "" {
+		versions, err := c.listVersions(tableName, name)
+		if err != nil {
+			return nil, err
+		}
+		// Rewriting soft deleted versions would bring them back as live ones
+		for _, cred := range versions {
+			if cred.DeletedAt == 0 && (opts.UpTo == "" || cred.Version <= opts.UpTo) {
+				creds = append(creds, cred)
+			}
+		}
+		if len(creds) == 0 {
+			return nil, &NotFoundError{Name: name, Table: tableName}
+		}
+		if !opts.AllVersions {
+			creds = creds[len(creds)-1:]
+		}
+	} else {
+		cred, err := c.getHighestVersionCredential(tableName, name)
+		if err != nil {
+			return nil, err
+		}
+		creds = []*Credential{cred}
+	}
+	if opts.Skip != nil {
+		var pending []*Credential
+		for _, cred := range creds {
+			if !opts.Skip(cred.Version) {
+				pending = append(pending, cred)
+			}
+		}
+		creds = pending
+	}
+
+	// Decrypt everything before writing, so a wrong context fails without partial rewrites
+	secrets := make([]*DecryptedCredential, len(creds))
+	for i, cred := range creds {
+		secret, err := c.decryptCredential(cred, ctx)
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = secret
+	}
+
+	// Soft deleted versions still hold their numbers, the copies continue after them
+	next, err := c.ResolveVersion(tableName, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	first, err := strconv.Atoi(next)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for i, secret := range secrets {
+		version := c.PaddedInt(first + i)
+		cred, err := c.encryptCredential(name, secret.Secret, version, newKey, opts.Context)
+		if err != nil {
+			return written, err
+		}
+		cred.Comment = secret.Comment
+		cred.Metadata = secret.Metadata
+		cred.ExpiresAt = secret.ExpiresAt
+
+		if err := c.putCredential(tableName, cred, nil); err != nil {
+			return written, err
+		}
+		log.Printf("[DEBUG] Re-encrypted %s version %s as version %s", name, secret.Version, version)
+		written = append(written, version)
+		if opts.Done != nil {
+			if err := opts.Done(secret.Version); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// listVersions returns every version of a secret, oldest first
+func (c *Client) listVersions(tableName string, name string) ([]*Credential, error) {
+	var creds []*Credential
+	input := &dynamodb.QueryInput{
+		TableName: &tableName,
+		ExpressionAttributeNames: map[string]*string{
+			"#N": aws.String("name"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":name": {
+				S: aws.String(name),
+			},
+		},
+		KeyConditionExpression: aws.String("#N = :name"),
+		ConsistentRead:         aws.Bool(true),
+		ScanIndexForward:       aws.Bool(true),
+	}
+	for {
+		res, err := c.dynamoDB.Query(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range res.Items {
+			cred := new(Credential)
+			if err := Decode(item, cred); err != nil {
+				return nil, err
+			}
+			creds = append(creds, cred)
+		}
+
+		if len(res.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+	return creds, nil
+}
@@ -0,0 +1,158 @@
+package credstash
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReencryptLatestVersion(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+	newCtx := NewEncryptionContextValue()
+	(*newCtx)["env"] = aws.String("prod")
+
+	written, err := c.Reencrypt("", "alpha", NewEncryptionContextValue(), ReencryptOptions{KmsKey: "alias/new", Context: newCtx})
+
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(3)}, written)
+	secret, err := c.GetHighestVersionSecret("", "alpha", newCtx)
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-2", secret.Secret)
+
+	_, err = c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+	var mismatch *ContextMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+}
+
+func TestReencryptAllVersionsKeepsOrder(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+
+	written, err := c.Reencrypt("", "alpha", NewEncryptionContextValue(), ReencryptOptions{KmsKey: "alias/new", Context: NewEncryptionContextValue(), AllVersions: true})
+
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(3), c.PaddedInt(4)}, written)
+	secret, err := c.GetSecret("alpha", "", c.PaddedInt(3), NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-1", secret.Secret)
+	secret, err = c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-2", secret.Secret)
+}
+
+func TestReencryptWrongContextWritesNothing(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	wrong := NewEncryptionContextValue()
+	(*wrong)["env"] = aws.String("prod")
+
+	_, err := c.Reencrypt("", "alpha", wrong, ReencryptOptions{KmsKey: "alias/new", Context: NewEncryptionContextValue(), AllVersions: true})
+
+	assertError(t, err)
+	version, err := c.GetLatestVersion("", "alpha")
+	assertNoError(t, err)
+	assert.Equal(t, 1, version)
+}
+
+func TestReencryptResumesPerVersion(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+	var done []string
+
+	written, err := c.Reencrypt("", "alpha", NewEncryptionContextValue(), ReencryptOptions{
+		Context:     NewEncryptionContextValue(),
+		AllVersions: true,
+		Skip:        func(version string) bool { return version == c.PaddedInt(1) },
+		Done: func(version string) error {
+			done = append(done, version)
+			return nil
+		},
+	})
+
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(3)}, written)
+	assert.Equal(t, []string{c.PaddedInt(2)}, done)
+	cred, err := c.getHighestVersionCredential("credential-store", "alpha")
+	assertNoError(t, err)
+	key, err := base64.StdEncoding.DecodeString(cred.Key)
+	assertNoError(t, err)
+	assert.True(t, strings.HasPrefix(string(key), DefaultKmsKey+"#"))
+	secret, err := c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-2", secret.Secret)
+}
+
+func TestReencryptResumeLeavesPartialCopies(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+	mustPutSecret(t, c, "alpha", "alpha-3", 3)
+	done := map[string]bool{}
+	opts := ReencryptOptions{
+		KmsKey:      "alias/new",
+		Context:     NewEncryptionContextValue(),
+		AllVersions: true,
+		UpTo:        c.PaddedInt(3),
+		Skip:        func(version string) bool { return done[version] },
+		Done: func(version string) error {
+			done[version] = true
+			if len(done) == 2 {
+				return errors.New("interrupted")
+			}
+			return nil
+		},
+	}
+
+	written, err := c.Reencrypt("", "alpha", NewEncryptionContextValue(), opts)
+	assertError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(4), c.PaddedInt(5)}, written)
+
+	opts.Done = func(version string) error { return nil }
+	written, err = c.Reencrypt("", "alpha", NewEncryptionContextValue(), opts)
+
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(6)}, written)
+	secret, err := c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-3", secret.Secret)
+}
+
+func TestReencryptLatestUpTo(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+
+	written, err := c.Reencrypt("", "alpha", NewEncryptionContextValue(), ReencryptOptions{KmsKey: "alias/new", Context: NewEncryptionContextValue(), UpTo: c.PaddedInt(1)})
+
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(3)}, written)
+	secret, err := c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-1", secret.Secret)
+}
+
+func TestReencryptAfterSoftDeletedVersion(t *testing.T) {
+	c, _ := newFakeClient()
+	c.opts.SoftDelete = true
+	c.opts.SoftDeleteWindow = 24 * time.Hour
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+	assertNoError(t, c.DeleteSecretVersion("", "alpha", c.PaddedInt(2)))
+
+	written, err := c.Reencrypt("", "alpha", NewEncryptionContextValue(), ReencryptOptions{KmsKey: "alias/new", Context: NewEncryptionContextValue()})
+
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(3)}, written)
+	secret, err := c.GetHighestVersionSecret("", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-1", secret.Secret)
+}
@@ -0,0 +1,70 @@
+package credstash
+
+import (
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// CopyOptions holds the destination settings of Copy
+type CopyOptions struct {
+	// Table is the destination table, the destination client's default table when empty
+	Table string
+	// KmsKey wraps the copy in the destination, DefaultKmsKey when empty
+	KmsKey string
+	// Context is the destination encryption context
+	Context *EncryptionContextValue
+}
+
+// ForRegion returns a client for the same default table in another region. It returns the
+// client itself when region is empty or the client's own region.
+func (c *Client) ForRegion(region string) *Client {
+	if region == "" || c.sess == nil || aws.StringValue(c.sess.Config.Region) == region {
+		return c
+	}
+	return NewWithOptions(c.table, c.sess.Copy(&aws.Config{Region: aws.String(region)}), c.opts)
+}
+
+// Region returns the region the client talks to, empty without a session
+func (c *Client) Region() string {
+	if c.sess == nil {
+		return ""
+	}
+	return aws.StringValue(c.sess.Config.Region)
+}
+
+// Copy reads the source secret, its latest version when src.Version is empty, and writes the
+// value with its comment, metadata and expiry to dst as the next version, re-wrapped under the
+// destination key and context. It returns the source version read and the destination version
+// written.
+func (c *Client) Copy(src SecretRef, dst *Client, opts CopyOptions) (string, string, error) {
+	log.Printf("Copying secret: %s", src.Name)
+
+	// A copy is not a use of the secret, so it must not hide it from unused secret reports
+	reader := c.WithoutAccessTracking()
+	var secret *DecryptedCredential
+	var err error
+	if src.Version == "" {
+		secret, err = reader.GetHighestVersionSecret(src.Table, src.Name, src.Context)
+	} else {
+		secret, err = reader.GetSecret(src.Name, src.Table, src.Version, src.Context)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	version, err := dst.ResolveVersion(opts.Table, src.Name, 0)
+	if err != nil {
+		return "", "", err
+	}
+	err = dst.PutSecretWithOptions(opts.Table, src.Name, secret.Secret, version, opts.Context, PutOptions{
+		KmsKey:    opts.KmsKey,
+		Comment:   secret.Comment,
+		Metadata:  secret.Metadata,
+		ExpiresAt: secret.ExpiresAt,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return secret.Version, version, nil
+}
@@ -0,0 +1,75 @@
+package credstash
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyLatestVersion(t *testing.T) {
+	src, _ := newFakeClient()
+	dst, _ := newFakeClient()
+	mustPutSecret(t, src, "alpha", "alpha-1", 1)
+	mustPutSecret(t, src, "alpha", "alpha-2", 2)
+	mustPutSecret(t, dst, "alpha", "old", 1)
+	ctx := NewEncryptionContextValue()
+	(*ctx)["region"] = aws.String("eu-west-1")
+
+	srcVersion, dstVersion, err := src.Copy(SecretRef{Name: "alpha", Context: NewEncryptionContextValue()}, dst, CopyOptions{
+		KmsKey:  "alias/replica",
+		Context: ctx,
+	})
+
+	assertNoError(t, err)
+	assert.Equal(t, src.PaddedInt(2), srcVersion)
+	assert.Equal(t, dst.PaddedInt(2), dstVersion)
+	secret, err := dst.GetHighestVersionSecret("", "alpha", ctx)
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-2", secret.Secret)
+}
+
+func TestCopyPinnedVersionToOtherTable(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "alpha", "alpha-1", 1)
+	mustPutSecret(t, c, "alpha", "alpha-2", 2)
+
+	srcVersion, dstVersion, err := c.Copy(SecretRef{Name: "alpha", Version: c.PaddedInt(1), Context: NewEncryptionContextValue()}, c, CopyOptions{
+		Table:   "replica-store",
+		Context: NewEncryptionContextValue(),
+	})
+
+	assertNoError(t, err)
+	assert.Equal(t, c.PaddedInt(1), srcVersion)
+	assert.Equal(t, c.PaddedInt(1), dstVersion)
+	secret, err := c.GetHighestVersionSecret("replica-store", "alpha", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "alpha-1", secret.Secret)
+}
+
+func TestCopyDoesNotTrackAccess(t *testing.T) {
+	src, db := newFakeClient()
+	dst, _ := newFakeClient()
+	src.opts.TrackAccess = true
+	mustPutSecret(t, src, "alpha", "alpha-1", 1)
+
+	_, _, err := src.Copy(SecretRef{Name: "alpha", Context: NewEncryptionContextValue()}, dst, CopyOptions{Context: NewEncryptionContextValue()})
+
+	assertNoError(t, err)
+	assert.Nil(t, db.Item("credential-store", "alpha", src.PaddedInt(1))["access_count"])
+	assert.Nil(t, db.Item("credential-store", "alpha", src.PaddedInt(1))["last_accessed_at"])
+}
+
+func TestCopyMissingSource(t *testing.T) {
+	src, _ := newFakeClient()
+	dst, _ := newFakeClient()
+
+	_, _, err := src.Copy(SecretRef{Name: "missing", Context: NewEncryptionContextValue()}, dst, CopyOptions{})
+
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestForRegionWithoutSession(t *testing.T) {
+	c, _ := newFakeClient()
+	assert.Same(t, c, c.ForRegion("eu-west-1"))
+}
@@ -86,13 +86,13 @@ func GetHighestVersion(svc dynamoDB, tableName *string, name string) (string, er
 	log.Print("[DEBUG]  Got to line 315")
 
 	if len(res.Items) == 0 {
-		return "", ErrSecretNotFound
+		return "", &NotFoundError{Name: name, Table: aws.StringValue(tableName)}
 	}
 
 	v := res.Items[0]["version"]
 
 	if v == nil {
-		return "", ErrSecretNotFound
+		return "", &NotFoundError{Name: name, Table: aws.StringValue(tableName)}
 	}
 
 	return aws.StringValue(v.S), nil
@@ -0,0 +1,51 @@
+package credstash
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// seedInfo separates the keys derived for generation from other uses of the master secret
+const seedInfo = "credstash generate seed v1"
+
+// SeedReader returns a deterministic source of randomness derived with HKDF-SHA256 from a master
+// secret, the name of the secret being generated and its padded version. Generators given the
+// reader return the same value for the same inputs, which makes the values as secret as the
+// master secret only. HKDF yields at most 8160 bytes and generators may read any number of
+// bytes, so the derived key drives an AES-CTR keystream instead.
+func SeedReader(master string, name string, paddedVersion string) io.Reader {
+	info := fmt.Sprintf("%s\x00%s\x00%s", seedInfo, name, paddedVersion)
+	key := make([]byte, 32)
+	// Reading 32 bytes from HKDF-SHA256 cannot fail
+	io.ReadFull(hkdf.New(sha256.New, []byte(master), nil, []byte(info)), key)
+	block, _ := aes.NewCipher(key)
+	return cipher.StreamReader{S: cipher.NewCTR(block, make([]byte, aes.BlockSize)), R: zeroReader{}}
+}
+
+// zeroReader reads endless zero bytes, so a cipher.StreamReader over it yields the keystream
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// SeedReaderFromSecret decrypts the latest version of the master secret seedName and returns
+// the SeedReader of a secret name at paddedVersion
+func (c *Client) SeedReaderFromSecret(table string, seedName string, ctx *EncryptionContextValue, name string, paddedVersion string) (io.Reader, error) {
+	master, err := c.GetHighestVersionSecret(table, seedName, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("seed %q: %w", seedName, err)
+	}
+	if master.Secret == "" {
+		return nil, fmt.Errorf("seed %q is empty", seedName)
+	}
+	return SeedReader(master.Secret, name, paddedVersion), nil
+}
@@ -0,0 +1,52 @@
+package credstash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedReaderIsDeterministic(t *testing.T) {
+	c, _ := newFakeClient()
+	generate := func(master, name, version string) string {
+		value, err := c.GeneratePassword(PasswordOptions{Length: 32, UseSymbols: true, Min: map[string]int{"numeric": 2}, Rand: SeedReader(master, name, version)})
+		assertNoError(t, err)
+		return value
+	}
+
+	first := generate("master", "app.db", "0000000000000000001")
+	assert.Equal(t, first, generate("master", "app.db", "0000000000000000001"))
+	assert.NotEqual(t, first, generate("master", "app.db", "0000000000000000002"))
+	assert.NotEqual(t, first, generate("master", "app.cache", "0000000000000000001"))
+	assert.NotEqual(t, first, generate("other", "app.db", "0000000000000000001"))
+}
+
+func TestSeedReaderHasNoLengthLimit(t *testing.T) {
+	c, _ := newFakeClient()
+
+	value, err := c.GenerateBytes(10000, "hex", SeedReader("master", "app.db", "0000000000000000001"))
+
+	assertNoError(t, err)
+	assert.Len(t, value, 20000)
+	again, err := c.GenerateBytes(10000, "hex", SeedReader("master", "app.db", "0000000000000000001"))
+	assertNoError(t, err)
+	assert.Equal(t, value, again)
+}
+
+func TestSeedReaderFromSecret(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "fixtures.seed", "old-master", 1)
+	mustPutSecret(t, c, "fixtures.seed", "master", 2)
+
+	r, err := c.SeedReaderFromSecret("", "fixtures.seed", NewEncryptionContextValue(), "app.db", c.PaddedInt(1))
+	assertNoError(t, err)
+	value, err := c.GenerateUUID(r)
+	assertNoError(t, err)
+	expected, err := c.GenerateUUID(SeedReader("master", "app.db", c.PaddedInt(1)))
+	assertNoError(t, err)
+	assert.Equal(t, expected, value)
+
+	_, err = c.SeedReaderFromSecret("", "missing.seed", NewEncryptionContextValue(), "app.db", c.PaddedInt(1))
+	assert.True(t, errors.Is(err, ErrSecretNotFound), err)
+}
@@ -0,0 +1,150 @@
+package credstash
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// softDelete marks the live versions of a secret with a deleted_at tombstone and returns them
+func (c *Client) softDelete(tableName string, name string, now time.Time) ([]string, error) {
+	creds, err := c.listVersions(tableName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, cred := range creds {
+		if cred.DeletedAt != 0 {
+			continue
+		}
+		if err := c.softDeleteVersion(tableName, name, cred.Version, now); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, cred.Version)
+	}
+	return deleted, nil
+}
+
+// softDeleteVersion marks one version of a secret with a deleted_at tombstone and the time it
+// may be purged after
+func (c *Client) softDeleteVersion(tableName string, name string, paddedVersion string, now time.Time) error {
+	log.Printf("[DEBUG] Soft deleting name: %s version: %v", name, paddedVersion)
+	// purge_after goes first, a version is only deleted once deleted_at is set
+	purgeAfter := &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(now.Add(c.opts.SoftDeleteWindow).Unix(), 10))}
+	if err := c.updateAttribute(tableName, name, paddedVersion, "purge_after", purgeAfter); err != nil {
+		return err
+	}
+	value := &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(now.Unix(), 10))}
+	return c.updateAttribute(tableName, name, paddedVersion, "deleted_at", value)
+}
+
+// Restore brings back the soft deleted versions of a secret that were not purged yet and
+// returns them
+func (c *Client) Restore(tableName string, name string) ([]string, error) {
+	tableName = c.TableName(tableName)
+	log.Printf("Restoring secret: %s", name)
+
+	creds, err := c.listVersions(tableName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored []string
+	for _, cred := range creds {
+		if cred.DeletedAt == 0 {
+			continue
+		}
+		if err := c.updateAttribute(tableName, name, cred.Version, "deleted_at", nil); err != nil {
+			return restored, err
+		}
+		if err := c.updateAttribute(tableName, name, cred.Version, "purge_after", nil); err != nil {
+			return restored, err
+		}
+		restored = append(restored, cred.Version)
+	}
+	if len(restored) == 0 {
+		return nil, &NotFoundError{Name: name, Table: tableName}
+	}
+	return restored, nil
+}
+
+// Purge removes the soft deleted versions whose purge_after time has passed and returns their
+// names and versions. Tombstones without purge_after are purged SoftDeleteWindow after they
+// were deleted. Versions restored meanwhile are left alone.
+func (c *Client) Purge(tableName string, now time.Time) ([]*Credential, error) {
+	tableName = c.TableName(tableName)
+	log.Print("Purging soft deleted secrets")
+
+	var expired []*Credential
+	input := &dynamodb.ScanInput{
+		TableName: &tableName,
+		ExpressionAttributeNames: map[string]*string{
+			"#N": aws.String("name"),
+			"#V": aws.String("version"),
+			"#D": aws.String("deleted_at"),
+			"#P": aws.String("purge_after"),
+		},
+		ProjectionExpression: aws.String("#N, #V, #D, #P"),
+	}
+	window := int64(c.opts.SoftDeleteWindow / time.Second)
+	for {
+		res, err := c.dynamoDB.Scan(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range res.Items {
+			cred := new(Credential)
+			if err := Decode(item, cred); err != nil {
+				return nil, err
+			}
+			if cred.DeletedAt == 0 {
+				continue
+			}
+			purgeAfter := cred.PurgeAfter
+			if purgeAfter == 0 {
+				purgeAfter = cred.DeletedAt + window
+			}
+			if purgeAfter <= now.Unix() {
+				expired = append(expired, cred)
+			}
+		}
+
+		if len(res.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+
+	var purged []*Credential
+	for _, cred := range expired {
+		log.Printf("[DEBUG] Purging name: %s version: %v", cred.Name, cred.Version)
+		_, err := c.dynamoDB.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: &tableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"name":    {S: aws.String(cred.Name)},
+				"version": {S: aws.String(cred.Version)},
+			},
+			ExpressionAttributeNames: map[string]*string{
+				"#D": aws.String("deleted_at"),
+			},
+			// A restore between the scan and the delete wins
+			ConditionExpression: aws.String("attribute_exists(#D)"),
+		})
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged = append(purged, cred)
+	}
+	return purged, nil
+}
@@ -0,0 +1,150 @@
+package credstash
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDeleteHidesVersions(t *testing.T) {
+	c, db := newFakeClient()
+	c.opts.SoftDelete = true
+	c.opts.SoftDeleteWindow = 24 * time.Hour
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.db", "two", 2)
+	mustPutSecret(t, c, "app.other", "other", 1)
+
+	deleted, err := c.DeleteSecretVersions("", "app.db")
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(1), c.PaddedInt(2)}, deleted)
+	assert.NotNil(t, db.Item("credential-store", "app.db", c.PaddedInt(2)), "soft deletes keep the item")
+
+	_, err = c.GetHighestVersionSecret("", "app.db", NewEncryptionContextValue())
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+	_, err = c.GetSecret("app.db", "", c.PaddedInt(1), NewEncryptionContextValue())
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+	_, err = c.GetLatestVersion("", "app.db")
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+	_, err = c.GetSecrets([]SecretRef{{Name: "app.db", Version: c.PaddedInt(2)}})
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+
+	creds, err := c.ListSecrets("")
+	assertNoError(t, err)
+	assert.Len(t, creds, 1)
+	assert.Equal(t, "app.other", creds[0].Name)
+
+	// A new secret with the same name does not reuse the deleted version numbers
+	version, err := c.ResolveVersion("", "app.db", 0)
+	assertNoError(t, err)
+	assert.Equal(t, c.PaddedInt(3), version)
+}
+
+func TestSoftDeleteSingleVersion(t *testing.T) {
+	c, db := newFakeClient()
+	c.opts.SoftDelete = true
+	c.opts.SoftDeleteWindow = 24 * time.Hour
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.db", "two", 2)
+
+	assertNoError(t, c.DeleteSecretVersion("", "app.db", c.PaddedInt(2)))
+	assertNoError(t, c.DeleteSecretVersion("", "app.db", c.PaddedInt(3)))
+
+	assert.NotNil(t, db.Item("credential-store", "app.db", c.PaddedInt(2)), "soft deletes keep the item")
+	assert.Nil(t, db.Item("credential-store", "app.db", c.PaddedInt(3)), "missing versions are not created")
+	secret, err := c.GetHighestVersionSecret("", "app.db", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "one", secret.Secret)
+}
+
+func TestRestore(t *testing.T) {
+	c, _ := newFakeClient()
+	c.opts.SoftDelete = true
+	c.opts.SoftDeleteWindow = 24 * time.Hour
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.db", "two", 2)
+	assertNoError(t, c.DeleteSecret("", "app.db"))
+
+	restored, err := c.Restore("", "app.db")
+	assertNoError(t, err)
+	assert.Equal(t, []string{c.PaddedInt(1), c.PaddedInt(2)}, restored)
+
+	secret, err := c.GetHighestVersionSecret("", "app.db", NewEncryptionContextValue())
+	assertNoError(t, err)
+	assert.Equal(t, "two", secret.Secret)
+
+	_, err = c.Restore("", "app.db")
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+}
+
+func TestPurge(t *testing.T) {
+	c, db := newFakeClient()
+	c.opts.SoftDelete = true
+	c.opts.SoftDeleteWindow = 24 * time.Hour
+	mustPutSecret(t, c, "app.old", "one", 1)
+	mustPutSecret(t, c, "app.recent", "one", 1)
+	mustPutSecret(t, c, "app.live", "one", 1)
+
+	now := time.Now()
+	_, err := c.softDelete("credential-store", "app.old", now.Add(-25*time.Hour))
+	assertNoError(t, err)
+	_, err = c.softDelete("credential-store", "app.recent", now.Add(-time.Hour))
+	assertNoError(t, err)
+
+	purged, err := c.Purge("", now)
+	assertNoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, "app.old", purged[0].Name)
+	assert.Nil(t, db.Item("credential-store", "app.old", c.PaddedInt(1)))
+	assert.NotNil(t, db.Item("credential-store", "app.recent", c.PaddedInt(1)))
+	assert.NotNil(t, db.Item("credential-store", "app.live", c.PaddedInt(1)))
+
+	// Restored versions are never purged
+	_, err = c.Restore("", "app.recent")
+	assertNoError(t, err)
+	purged, err = c.Purge("", now.Add(48*time.Hour))
+	assertNoError(t, err)
+	assert.Empty(t, purged)
+}
+
+func TestPurgeUsesTheStoredWindow(t *testing.T) {
+	c, db := newFakeClient()
+	c.opts.SoftDelete = true
+	c.opts.SoftDeleteWindow = time.Hour
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.legacy", "one", 1)
+
+	now := time.Now()
+	_, err := c.softDelete("credential-store", "app.db", now.Add(-2*time.Hour))
+	assertNoError(t, err)
+	// Tombstones written before purge_after existed only hold deleted_at
+	deletedAt := &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(now.Add(-2*time.Hour).Unix(), 10))}
+	assertNoError(t, c.updateAttribute("credential-store", "app.legacy", c.PaddedInt(1), "deleted_at", deletedAt))
+
+	// The purge window no longer matters to versions deleted with a window of their own
+	c.opts.SoftDeleteWindow = 24 * time.Hour
+	purged, err := c.Purge("", now)
+	assertNoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, "app.db", purged[0].Name)
+	assert.NotNil(t, db.Item("credential-store", "app.legacy", c.PaddedInt(1)))
+
+	c.opts.SoftDeleteWindow = time.Hour
+	purged, err = c.Purge("", now)
+	assertNoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, "app.legacy", purged[0].Name)
+}
+
+func TestDeleteWithoutSoftDeleteRemovesItems(t *testing.T) {
+	c, db := newFakeClient()
+	mustPutSecret(t, c, "app.db", "one", 1)
+
+	assertNoError(t, c.DeleteSecret("", "app.db"))
+
+	assert.Nil(t, db.Item("credential-store", "app.db", c.PaddedInt(1)))
+}
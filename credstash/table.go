@@ -0,0 +1,76 @@
+package credstash
+
+import (
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TableExists reports whether the credential store table exists
+func (c *Client) TableExists(tableName string) (bool, error) {
+	tableName = c.TableName(tableName)
+
+	_, err := c.dynamoDB.DescribeTable(&dynamodb.DescribeTableInput{TableName: &tableName})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateTable creates a credential store table with the same key schema and throughput
+// as `credstash setup`, waits until it is active and applies the given tags
+func (c *Client) CreateTable(tableName string, tags map[string]string) error {
+	tableName = c.TableName(tableName)
+	log.Printf("[DEBUG] Creating table: %s", tableName)
+
+	out, err := c.dynamoDB.CreateTable(&dynamodb.CreateTableInput{
+		TableName: &tableName,
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("name"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			{AttributeName: aws.String("version"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+		},
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("name"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+			{AttributeName: aws.String("version"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(1),
+			WriteCapacityUnits: aws.Int64(1),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.dynamoDB.WaitUntilTableExists(&dynamodb.DescribeTableInput{TableName: &tableName})
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var dynamoTags []*dynamodb.Tag
+	for _, k := range keys {
+		dynamoTags = append(dynamoTags, &dynamodb.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	_, err = c.dynamoDB.TagResource(&dynamodb.TagResourceInput{
+		ResourceArn: out.TableDescription.TableArn,
+		Tags:        dynamoTags,
+	})
+	return err
+}
@@ -0,0 +1,23 @@
+package credstash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTable(t *testing.T) {
+	c, db := newFakeClient()
+
+	exists, err := c.TableExists("new-table")
+	assertNoError(t, err)
+	assert.False(t, exists)
+
+	err = c.CreateTable("new-table", map[string]string{"team": "platform"})
+	assertNoError(t, err)
+
+	exists, err = c.TableExists("new-table")
+	assertNoError(t, err)
+	assert.True(t, exists)
+	assert.Len(t, db.Tags["arn:aws:dynamodb:us-east-1:123456789012:table/new-table"], 1)
+}
@@ -0,0 +1,55 @@
+package credstash
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TTLAttribute is the item attribute DynamoDB TTL must be enabled on for
+// Options.SupersededVersionTTL to delete superseded versions
+const TTLAttribute = "ttl"
+
+// EnableTTL enables DynamoDB TTL on TTLAttribute of the credential store table
+func (c *Client) EnableTTL(tableName string) error {
+	tableName = c.TableName(tableName)
+	log.Printf("[DEBUG] Enabling TTL on table: %s", tableName)
+
+	_, err := c.dynamoDB.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
+		TableName: &tableName,
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String(TTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return err
+}
+
+// expireSuperseded sets the TTL of every version older than version that has none yet and is
+// not pinned. The version just written, and so the latest one, never gets a TTL.
+func (c *Client) expireSuperseded(tableName string, name string, version string, pinned []string, now time.Time) error {
+	creds, err := c.listVersions(tableName, name)
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool, len(pinned))
+	for _, v := range pinned {
+		keep[v] = true
+	}
+	ttl := now.Add(c.opts.SupersededVersionTTL).Unix()
+	for _, cred := range creds {
+		if cred.Version >= version || cred.TTL != 0 || keep[cred.Version] {
+			continue
+		}
+		value := &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(ttl, 10))}
+		if err := c.updateAttribute(tableName, name, cred.Version, TTLAttribute, value); err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] Superseded version %s of %s expires at %d", cred.Version, name, ttl)
+	}
+	return nil
+}
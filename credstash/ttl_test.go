@@ -0,0 +1,72 @@
+package credstash
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutSecretExpiresSupersededVersions(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.other", "other", 1)
+	c.opts.SupersededVersionTTL = 24 * time.Hour
+
+	before := time.Now().Add(24 * time.Hour).Unix()
+	mustPutSecret(t, c, "app.db", "two", 2)
+	versions, err := c.listVersions("credential-store", "app.db")
+	assertNoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.GreaterOrEqual(t, versions[0].TTL, before)
+	assert.Zero(t, versions[1].TTL, "the latest version must never expire")
+
+	// Versions already expiring keep their TTL
+	ttl := versions[0].TTL
+	mustPutSecret(t, c, "app.db", "three", 3)
+	versions, err = c.listVersions("credential-store", "app.db")
+	assertNoError(t, err)
+	assert.Equal(t, ttl, versions[0].TTL)
+	assert.NotZero(t, versions[1].TTL)
+	assert.Zero(t, versions[2].TTL)
+
+	other, err := c.listVersions("credential-store", "app.other")
+	assertNoError(t, err)
+	assert.Zero(t, other[0].TTL)
+}
+
+func TestPutSecretKeepsPinnedVersions(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.db", "two", 2)
+	c.opts.SupersededVersionTTL = 24 * time.Hour
+
+	err := c.PutSecretWithOptions("", "app.db", "three", c.PaddedInt(3), NewEncryptionContextValue(), PutOptions{
+		Pinned: []string{c.PaddedInt(1)},
+	})
+
+	assertNoError(t, err)
+	versions, err := c.listVersions("credential-store", "app.db")
+	assertNoError(t, err)
+	assert.Zero(t, versions[0].TTL, "pinned versions must never expire")
+	assert.NotZero(t, versions[1].TTL)
+	assert.Zero(t, versions[2].TTL)
+}
+
+func TestPutSecretWithoutTTLKeepsVersions(t *testing.T) {
+	c, _ := newFakeClient()
+	mustPutSecret(t, c, "app.db", "one", 1)
+	mustPutSecret(t, c, "app.db", "two", 2)
+
+	versions, err := c.listVersions("credential-store", "app.db")
+	assertNoError(t, err)
+	assert.Zero(t, versions[0].TTL)
+}
+
+func TestEnableTTL(t *testing.T) {
+	c, db := newFakeClient()
+
+	assertNoError(t, c.EnableTTL(""))
+
+	assert.Equal(t, TTLAttribute, db.TTLAttribute["credential-store"])
+}
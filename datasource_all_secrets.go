@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/granular-oss/terraform-provider-credstash/render"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAllSecrets() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAllSecretsRead,
+
+		Schema: map[string]*schema.Schema{
+			"prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "only return secrets whose name starts with this prefix",
+				Default:     "",
+			},
+			"strip_prefix": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "remove `prefix` from the names used as keys of `values`, a secret named `prefix` itself is an error",
+				Default:     false,
+			},
+			"table": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "name of DynamoDB table where the secrets are stored",
+				Default:     "",
+			},
+			"context": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "encryption context for the secrets",
+			},
+			"values": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "latest values of the secrets keyed by name",
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func dataSourceAllSecretsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	client := meta.(*credstash.Client)
+
+	table := d.Get("table").(string)
+	prefix := d.Get("prefix").(string)
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
+
+	tflog.Debug(ctx, "dataSourceAllSecretsRead getting secrets", map[string]interface{}{
+		"prefix":  prefix,
+		"table":   table,
+		"context": context,
+	})
+
+	secrets, err := client.GetAllSecrets(table, prefix, context)
+	if err != nil {
+		return secretErrorDiags(err)
+	}
+
+	if d.Get("strip_prefix").(bool) {
+		// The secret named after the prefix itself would have an empty key
+		if secrets, err = render.TransformKeys(secrets, prefix, "none"); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	values := make(map[string]interface{}, len(secrets))
+	for name, secret := range secrets {
+		values[name] = secret
+	}
+	d.Set("values", values)
+	d.SetId(hash(client.TableName(table) + "/" + prefix))
+
+	return diags
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAudit() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAuditRead,
+
+		Schema: map[string]*schema.Schema{
+			"table": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "name of DynamoDB table to verify",
+				Default:     "",
+			},
+			"context": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "encryption context used to decrypt every item, items stored with another context are reported as undecryptable",
+			},
+			"fail_on_findings": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "fail the read instead of warning when any item does not verify",
+			},
+			"items": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "number of items scanned",
+			},
+			"verified": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "number of items that decrypted and passed HMAC validation",
+			},
+			"findings": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "items that failed verification, sorted by name and version",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "name of the secret",
+						},
+						"version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "version of the secret as stored",
+						},
+						"problem": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "`corrupt`, `undecryptable` or `malformed`",
+						},
+						"detail": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "the error the item failed with",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAuditRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	client := meta.(*credstash.Client)
+
+	table := d.Get("table").(string)
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
+
+	tflog.Debug(ctx, "dataSourceAuditRead verifying table", map[string]interface{}{
+		"table":   table,
+		"context": context,
+	})
+
+	report, err := client.Verify(table, context)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	findings := make([]interface{}, 0, len(report.Findings))
+	var lines []string
+	for _, finding := range report.Findings {
+		findings = append(findings, map[string]interface{}{
+			"name":    finding.Name,
+			"version": finding.Version,
+			"problem": string(finding.Problem),
+			"detail":  finding.Err.Error(),
+		})
+		lines = append(lines, fmt.Sprintf("%s version %s: %s, %v", finding.Name, finding.Version, finding.Problem, finding.Err))
+	}
+	d.Set("items", report.Items)
+	d.Set("verified", report.Verified)
+	d.Set("findings", findings)
+	d.SetId(client.TableName(table))
+
+	if len(report.Findings) > 0 {
+		severity := diag.Warning
+		if d.Get("fail_on_findings").(bool) {
+			severity = diag.Error
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  fmt.Sprintf("%d of %d items in %s failed verification", len(report.Findings), report.Items, client.TableName(table)),
+			Detail:   strings.Join(lines, "\n"),
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/granular-oss/terraform-provider-credstash/render"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceRendered() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRenderedRead,
+
+		Schema: map[string]*schema.Schema{
+			"names": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MinItems:     1,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				Description:  "names of the secrets to render. Either `names` or `prefix` must be defined.",
+				ExactlyOneOf: []string{"names", "prefix"},
+			},
+			"prefix": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "render the latest version of every secret whose name starts with this prefix. Either `names` or `prefix` must be defined.",
+				ExactlyOneOf: []string{"names", "prefix"},
+			},
+			"strip_prefix": {
+				Type:         schema.TypeBool,
+				Optional:     true,
+				Default:      false,
+				RequiredWith: []string{"prefix"},
+				Description:  "remove `prefix` from the names before rendering them as keys",
+			},
+			"key_transform": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "none",
+				ValidateFunc: validation.StringInSlice(render.KeyTransforms, false),
+				Description:  "how names are turned into keys: `none` or `upper_snake`",
+			},
+			"format": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(render.Formats, false),
+				Description:  "output format: " + strings.Join(render.Formats, ", "),
+			},
+			"table": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "name of DynamoDB table where the secrets are stored",
+				Default:     "",
+			},
+			"context": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "encryption context for the secrets",
+			},
+			"rendered": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "the secrets rendered in the requested format",
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func dataSourceRenderedRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	client := meta.(*credstash.Client)
+
+	table := d.Get("table").(string)
+	prefix := d.Get("prefix").(string)
+	format := d.Get("format").(string)
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
+
+	var names []string
+	for _, raw := range d.Get("names").([]interface{}) {
+		names = append(names, raw.(string))
+	}
+
+	tflog.Debug(ctx, "dataSourceRenderedRead getting secrets", map[string]interface{}{
+		"names":   names,
+		"prefix":  prefix,
+		"table":   table,
+		"format":  format,
+		"context": context,
+	})
+
+	var secrets map[string]string
+	if len(names) > 0 {
+		refs := make([]credstash.SecretRef, len(names))
+		for i, name := range names {
+			refs[i] = credstash.SecretRef{Table: table, Name: name, Context: context}
+		}
+		decrypted, err := client.GetSecrets(refs)
+		if err != nil {
+			return secretErrorDiags(err)
+		}
+		secrets = make(map[string]string, len(decrypted))
+		for _, secret := range decrypted {
+			secrets[secret.Name] = secret.Secret
+		}
+	} else {
+		var err error
+		secrets, err = client.GetAllSecrets(table, prefix, context)
+		if err != nil {
+			return secretErrorDiags(err)
+		}
+	}
+
+	strip := ""
+	if d.Get("strip_prefix").(bool) {
+		strip = prefix
+	}
+	keyed, err := render.TransformKeys(secrets, strip, d.Get("key_transform").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	rendered, err := render.Render(keyed, format)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("rendered", rendered)
+	d.SetId(hash(client.TableName(table) + "/" + prefix + strings.Join(names, ",") + "/" + format))
+
+	return diags
+}
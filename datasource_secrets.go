@@ -5,11 +5,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"time"
 
 	"github.com/granular-oss/terraform-provider-credstash/credstash"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func dataSourceSecret() *schema.Resource {
@@ -45,10 +47,44 @@ func dataSourceSecret() *schema.Resource {
 				Description: "value of the secret",
 				Sensitive:   true,
 			},
+			"metadata": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The metadata stored with the secret.",
+			},
+			"expires_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The RFC 3339 time the version expires at, empty when it never expires.",
+			},
+			"on_expired": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      expiredActionError,
+				ValidateFunc: validation.StringInSlice([]string{expiredActionError, expiredActionWarn, expiredActionIgnore}, false),
+				Description:  "What to do when the version read is past its `expires_at`: `error`, `warn` or `ignore`.",
+			},
+			"last_accessed_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The RFC 3339 time the version was last read with the provider `track_access`, including this read. Empty when it never was.",
+			},
+			"access_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of reads of the version with the provider `track_access`, including this read.",
+			},
 		},
 	}
 }
 
+const (
+	expiredActionError  = "error"
+	expiredActionWarn   = "warn"
+	expiredActionIgnore = "ignore"
+)
+
 func dataSourceSecretRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -59,11 +95,7 @@ func dataSourceSecretRead(ctx context.Context, d *schema.ResourceData, meta inte
 	version := d.Get("version").(int)
 	table := d.Get("table").(string)
 
-	context := credstash.NewEncryptionContextValue()
-	for k, v := range d.Get("context").(map[string]interface{}) {
-		stringValue := fmt.Sprintf("%v", v)
-		(*context)[k] = &stringValue
-	}
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
 
 	var value *credstash.DecryptedCredential
 	var err error
@@ -83,14 +115,40 @@ func dataSourceSecretRead(ctx context.Context, d *schema.ResourceData, meta inte
 
 	}
 	if err != nil {
-		return diag.FromErr(err)
+		return secretErrorDiags(err)
+	}
+	if err := value.CheckExpiry(time.Now()); err != nil {
+		switch d.Get("on_expired").(string) {
+		case expiredActionError:
+			return secretErrorDiags(err)
+		case expiredActionWarn:
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Secret expired",
+				Detail:   err.Error() + ". Rotate the secret, it is only read because `on_expired` is `warn`.",
+			})
+		}
 	}
 	d.Set("value", value.Secret)
-	d.SetId(hash(value.Secret))
+	d.Set("metadata", value.Metadata)
+	d.Set("expires_at", formatTimestamp(value.ExpiresAt))
+	d.Set("last_accessed_at", formatTimestamp(value.LastAccessedAt))
+	d.Set("access_count", value.AccessCount)
+	d.SetId(secretID(client.TableName(table), name))
 
 	return diags
 }
 
+// encryptionContext converts a Terraform context map into a KMS encryption context
+func encryptionContext(raw map[string]interface{}) *credstash.EncryptionContextValue {
+	context := credstash.NewEncryptionContextValue()
+	for k, v := range raw {
+		stringValue := fmt.Sprintf("%v", v)
+		(*context)[k] = &stringValue
+	}
+	return context
+}
+
 func hash(s string) string {
 	sha := sha256.Sum256([]byte(s))
 	return hex.EncodeToString(sha[:])
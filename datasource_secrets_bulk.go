@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceSecretsBulk() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSecretsBulkRead,
+
+		Schema: map[string]*schema.Schema{
+			"names": {
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "names of the secrets to read",
+			},
+			"versions": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "pinned versions keyed by secret name, secrets not listed resolve to their latest version",
+			},
+			"table": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "name of DynamoDB table where the secrets are stored",
+				Default:     "",
+			},
+			"context": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "encryption context for the secrets",
+			},
+			"values": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "values of the secrets keyed by name",
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func dataSourceSecretsBulkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	client := meta.(*credstash.Client)
+
+	table := d.Get("table").(string)
+	versions := d.Get("versions").(map[string]interface{})
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
+
+	var names []string
+	var refs []credstash.SecretRef
+	for _, raw := range d.Get("names").([]interface{}) {
+		name := raw.(string)
+		ref := credstash.SecretRef{Table: table, Name: name, Context: context}
+		if version, ok := versions[name]; ok && version.(int) != 0 {
+			ref.Version = client.PaddedInt(version.(int))
+		}
+		names = append(names, name)
+		refs = append(refs, ref)
+	}
+
+	tflog.Debug(ctx, "dataSourceSecretsBulkRead getting secrets", map[string]interface{}{
+		"names":   names,
+		"table":   table,
+		"context": context,
+	})
+
+	secrets, err := client.GetSecrets(refs)
+	if err != nil {
+		return secretErrorDiags(err)
+	}
+
+	values := make(map[string]interface{}, len(secrets))
+	for i, secret := range secrets {
+		values[names[i]] = secret.Secret
+	}
+	d.Set("values", values)
+	d.SetId(hash(table + "/" + strings.Join(names, ",")))
+
+	return diags
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// secretErrorDiags turns errors returned by the credstash client into diagnostics with a short
+// summary and a detail explaining the likely cause. Unknown errors fall back to diag.FromErr.
+func secretErrorDiags(err error) diag.Diagnostics {
+	var notFound *credstash.NotFoundError
+	var accessDenied *credstash.AccessDeniedError
+	var contextMismatch *credstash.ContextMismatchError
+	var integrity *credstash.IntegrityError
+	var expired *credstash.ExpiredError
+
+	switch {
+	case errors.As(err, &notFound):
+		return errorDiag("Secret not found", fmt.Sprintf("%s. Check the name, version and table, "+
+			"or whether the secret was deleted outside of Terraform.", notFound.Error()))
+	case errors.As(err, &accessDenied):
+		return errorDiag("KMS access denied", fmt.Sprintf("%s.\n\nThe AWS credentials used by the provider "+
+			"need kms:Decrypt on the key that wrapped this secret, and the key policy must allow it.", accessDenied.Error()))
+	case errors.As(err, &contextMismatch):
+		return errorDiag("Encryption context mismatch", fmt.Sprintf("%s.\n\nThe `context` must contain exactly "+
+			"the key/value pairs the secret was stored with.", contextMismatch.Error()))
+	case errors.As(err, &integrity):
+		return errorDiag("Secret integrity check failed", fmt.Sprintf("%s.\n\nThe stored item is malformed or "+
+			"was modified after it was written and should not be trusted.", integrity.Error()))
+	case errors.As(err, &expired):
+		return errorDiag("Secret expired", fmt.Sprintf("%s.\n\nRotate the secret, or set `on_expired` to `warn` "+
+			"to read it anyway.", expired.Error()))
+	}
+	return diag.FromErr(err)
+}
+
+func errorDiag(summary string, detail string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  summary,
+		Detail:   detail,
+	}}
+}
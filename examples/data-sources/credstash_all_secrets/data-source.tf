@@ -0,0 +1,13 @@
+# Read the latest value of every secret named "app.*", keyed without the prefix
+data "credstash_all_secrets" "app" {
+  prefix       = "app."
+  strip_prefix = true
+}
+
+# Pass them all to a container as environment variables
+resource "aws_ecs_task_definition" "app" {
+  container_definitions = jsonencode([{
+    name        = "app"
+    environment = [for k, v in data.credstash_all_secrets.app.values : { name = upper(k), value = v }]
+  }])
+}
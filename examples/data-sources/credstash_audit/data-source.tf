@@ -0,0 +1,9 @@
+# Verify the HMAC of every version of every secret in the default table and fail the
+# plan when any item is corrupt, undecryptable or malformed
+data "credstash_audit" "store" {
+  fail_on_findings = true
+}
+
+output "unverified_secrets" {
+  value = [for f in data.credstash_audit.store.findings : "${f.name}@${f.version}: ${f.problem}"]
+}
@@ -0,0 +1,23 @@
+# Render every "app." secret as a .env file with keys like DB_PASSWORD
+data "credstash_rendered" "dotenv" {
+  prefix        = "app."
+  strip_prefix  = true
+  key_transform = "upper_snake"
+  format        = "dotenv"
+}
+
+# Render selected secrets as YAML for a Kubernetes secret
+data "credstash_rendered" "config" {
+  names  = ["app.db_password", "app.api_token"]
+  format = "yaml"
+}
+
+resource "kubernetes_secret" "app" {
+  metadata {
+    name = "app"
+  }
+  data = {
+    ".env"        = data.credstash_rendered.dotenv.rendered
+    "config.yaml" = data.credstash_rendered.config.rendered
+  }
+}
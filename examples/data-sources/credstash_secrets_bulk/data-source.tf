@@ -0,0 +1,12 @@
+# Read several secrets from credstash in one round trip, pinning "rds_password" to version 2
+data "credstash_secrets_bulk" "app" {
+  names = ["rds_password", "api_token", "signing_key"]
+  versions = {
+    rds_password = 2
+  }
+}
+
+# Use one of the values read above in another resource.
+resource "aws_db_instance" "postgres" {
+  password = data.credstash_secrets_bulk.app.values["rds_password"]
+}
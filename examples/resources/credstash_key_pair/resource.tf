@@ -0,0 +1,18 @@
+# Generate an Ed25519 SSH key, store the private key in credstash as "deploy.ssh_key"
+# and authorize the public key on an instance
+resource "credstash_key_pair" "deploy" {
+  name      = "deploy.ssh_key"
+  algorithm = "ED25519"
+}
+
+resource "aws_key_pair" "deploy" {
+  key_name   = "deploy"
+  public_key = credstash_key_pair.deploy.public_key_openssh
+}
+
+# Generate an RSA key for a TLS certificate request
+resource "credstash_key_pair" "tls" {
+  name      = "api.tls_key"
+  algorithm = "RSA"
+  rsa_bits  = 3072
+}
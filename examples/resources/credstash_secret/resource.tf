@@ -12,3 +12,103 @@ resource "credstash_secret" "my_pub_key" {
   name  = "my_pub_key"
   value = file("${path.root}/id_rsa.pub")
 }
+
+# Generate a memorable passphrase like "Ladle-Mumble-Thicket7-Unsaid-Overtly-Gumball"
+resource "credstash_secret" "vpn_passphrase" {
+  name = "vpn.passphrase"
+  generate {
+    type           = "passphrase"
+    words          = 6
+    capitalize     = true
+    include_number = true
+  }
+}
+
+# Generate an API token shaped like "sk_live_" followed by 24 random letters and digits.
+# Classes are alnum, alpha, digit, lower, upper, hex, HEX, symbols, human-readable and all.
+resource "credstash_secret" "api_token" {
+  name = "api.token"
+  generate {
+    type     = "template"
+    template = "sk_live_{alnum:24}"
+  }
+}
+
+# Generate a 32 byte signing key encoded as URL safe base64
+resource "credstash_secret" "signing_key" {
+  name = "signing.key"
+  generate {
+    type  = "base64url"
+    bytes = 32
+  }
+}
+
+# Generate a password for a legacy system rejecting quotes and backslashes,
+# entropy_bits shows how much strength the constraints cost
+resource "credstash_secret" "legacy_password" {
+  name = "legacy.password"
+  generate {
+    length        = 24
+    exclude_chars = "\"'`\\"
+    no_ambiguous  = true
+  }
+}
+
+# Reproducible password for an ephemeral test environment, derived from the
+# "fixtures.seed" secret. Never use a seed for production secrets.
+resource "credstash_secret" "fixture_password" {
+  name = "test.fixture_password"
+  generate {
+    length = 24
+    seed   = "fixtures.seed"
+  }
+}
+
+# Store a certificate from a file, a new version is written whenever the file changes
+resource "credstash_secret" "tls_cert" {
+  name        = "tls.cert"
+  source_file = "${path.module}/certs/server.pem"
+}
+
+# Record who owns a secret and why, without writing a new version when it changes
+resource "credstash_secret" "billing_api_key" {
+  name  = "billing.api_key"
+  value = var.billing_api_key
+  metadata = {
+    owner   = "billing-team"
+    ticket  = "SEC-1234"
+    purpose = "payment provider API access"
+  }
+}
+
+# Expire every version after 90 days and generate a new one when less than a week is left.
+# Readers using the credstash_secret data source fail on expired versions.
+resource "credstash_secret" "service_token" {
+  name          = "service.token"
+  valid_for     = "2160h"
+  rotate_before = "168h"
+  generate {
+    length = 40
+  }
+}
+
+# Reject supplied values that do not meet the password policy, without showing them in the plan
+resource "credstash_secret" "legacy_db_password" {
+  name  = "legacy.db_password"
+  value = var.legacy_db_password
+  policy {
+    min_length       = 16
+    require          = { uppercase = 1, numeric = 2, symbols = 1 }
+    forbid_chars     = "\"'`"
+    min_entropy_bits = 80
+  }
+}
+
+# Fail any destroy, including replacements from refactors, until protected is set to false and applied
+resource "credstash_secret" "prod_db_password" {
+  name      = "prod.db_password"
+  protected = true
+  generate {
+    length = 32
+  }
+}
@@ -0,0 +1,16 @@
+# Replicate "db.password" into the credential store of a second region, re-wrapped
+# under that region's KMS key. New source versions are copied on the next apply.
+resource "credstash_secret_replica" "db_password" {
+  name               = "db.password"
+  destination_region = "us-west-2"
+  kms_key            = "alias/credstash"
+}
+
+# Copy a pinned version into another table, with a different encryption context
+resource "credstash_secret_replica" "api_key" {
+  name              = "api.key"
+  version           = 3
+  source_context    = { env = "prod" }
+  destination_table = "credential-store-dr"
+  context           = { env = "dr" }
+}
@@ -3,11 +3,23 @@ package test
 import (
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
+// credstashCommand returns the credstash CLI to run. It defaults to the Python `credstash` but can be
+// pointed at the provider binary with CREDSTASH_CLI="terraform-provider-credstash cli".
+func credstashCommand(args ...string) *exec.Cmd {
+	command := strings.Fields(os.Getenv("CREDSTASH_CLI"))
+	if len(command) == 0 {
+		command = []string{"credstash"}
+	}
+	return exec.Command(command[0], append(command[1:], args...)...)
+}
+
 // Instead of specifying the table name for each CLI function, we create an object so we can specify it once
 type credstashCli struct {
 	tableName string
@@ -29,7 +41,7 @@ func newCredstashCliCustomTable(tableName string) *credstashCli {
 
 func (cli *credstashCli) list() {
 	args := []string{"-r", "us-east-1", "-t", cli.tableName, "list"}
-	out, err := exec.Command("credstash", args...).CombinedOutput()
+	out, err := credstashCommand(args...).CombinedOutput()
 	fmt.Println("ran credstash")
 	if err != nil {
 		log.Println(string(out))
@@ -50,7 +62,7 @@ func (cli *credstashCli) put(name string, value string, version int) {
 		args = append(args, "-v", strconv.Itoa(version), name, value)
 	}
 
-	out, err := exec.Command("credstash", args...).Output()
+	out, err := credstashCommand(args...).Output()
 
 	if err != nil {
 		log.Println(string(out))
@@ -63,7 +75,7 @@ Delete a credstash secret. Will Delete all version
 */
 func (cli *credstashCli) delete(name string) {
 	args := []string{"-t", cli.tableName, "delete", name}
-	out, err := exec.Command("credstash", args...).CombinedOutput()
+	out, err := credstashCommand(args...).CombinedOutput()
 
 	if err != nil {
 		log.Println(string(out))
@@ -82,7 +94,7 @@ func (cli *credstashCli) get(name string, version int) string {
 		args = append(args, "-v", strconv.Itoa(version), name)
 	}
 
-	out, err := exec.Command("credstash", args...).Output()
+	out, err := credstashCommand(args...).Output()
 
 	if err != nil {
 		log.Println(args)
@@ -99,6 +111,9 @@ Get the last version number of a secret from credstash
 func (cli *credstashCli) getLatestVersion(name string) string {
 	// cmdString := "credstash -t " + cli.tableName + " list | grep " + name + " | tail -1 | sed --regexp-extended 's/.*?version 0*([1-9][0-9]*).*/\\1/'"
 	cmdString := "credstash -t " + cli.tableName + " list | grep " + name + " | tail -1"
+	if command := os.Getenv("CREDSTASH_CLI"); command != "" {
+		cmdString = command + " -t " + cli.tableName + " list | grep " + name + " | tail -1"
+	}
 	out, err := exec.Command("bash", "-c", cmdString).Output()
 	re := regexp.MustCompile(`.*?version 0*([1-9][0-9]*).*`)
 	version := re.FindStringSubmatch(string(out))
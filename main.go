@@ -1,11 +1,19 @@
 package main
 
 import (
+	"os"
+
+	"github.com/granular-oss/terraform-provider-credstash/cli"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 )
 
 func main() {
+	// `terraform-provider-credstash cli ...` runs the credstash compatible CLI instead of the plugin
+	if len(os.Args) > 1 && os.Args[1] == "cli" {
+		os.Exit(cli.Run(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: func() *schema.Provider {
 			return Provider()
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -17,10 +18,16 @@ func Provider() *schema.Provider {
 	// rovider that enables reading and creating of secrets with credstash
 	return &schema.Provider{
 		DataSourcesMap: map[string]*schema.Resource{
-			"credstash_secret": dataSourceSecret(),
+			"credstash_all_secrets":  dataSourceAllSecrets(),
+			"credstash_audit":        dataSourceAudit(),
+			"credstash_rendered":     dataSourceRendered(),
+			"credstash_secret":       dataSourceSecret(),
+			"credstash_secrets_bulk": dataSourceSecretsBulk(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"credstash_secret": resourceSecret(),
+			"credstash_key_pair":       resourceKeyPair(),
+			"credstash_secret":         resourceSecret(),
+			"credstash_secret_replica": resourceSecretReplica(),
 		},
 		Schema: map[string]*schema.Schema{
 			"region": {
@@ -45,6 +52,37 @@ func Provider() *schema.Provider {
 				Default:     defaultAWSProfile,
 				Description: "The profile that should be used to connect to AWS",
 			},
+			"superseded_version_ttl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description: "When set, writing a new version of a secret sets the `ttl` attribute of the older versions " +
+					"to now plus this duration, like `720h`, so DynamoDB deletes them. The latest version never expires. " +
+					"Pinning an old version with `version` is incompatible with this setting, other writers still expire it. " +
+					"TTL must be enabled on the `ttl` attribute of the table, see `cli setup -ttl`.",
+			},
+			"soft_delete_window": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description: "When set, destroying a secret marks its versions with a `deleted_at` tombstone instead of " +
+					"removing them. Deleted secrets are hidden from reads and can be brought back with `cli restore` " +
+					"for this duration, like `720h`. The tombstone records when the duration ends as `purge_after`, " +
+					"and `cli purge` removes the versions after it.",
+			},
+			"protected_prefixes": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Name prefixes of `credstash_secret` resources that are `protected` unless they set it to `false`.",
+			},
+			"track_access": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "Whether to record `last_accessed_at` and `access_count` on every version read, to find unused secrets. " +
+					"Refreshes of `credstash_secret` and `credstash_key_pair` resources are not counted.",
+			},
 		},
 		ConfigureContextFunc: providerConfig,
 	}
@@ -55,6 +93,27 @@ func providerConfig(ctx context.Context, d *schema.ResourceData) (interface{}, d
 	table := d.Get("table").(string)
 	profile := d.Get("profile").(string)
 
+	var opts credstash.Options
+	if ttl := d.Get("superseded_version_ttl").(string); ttl != "" {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		opts.SupersededVersionTTL = duration
+	}
+	if window := d.Get("soft_delete_window").(string); window != "" {
+		duration, err := time.ParseDuration(window)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		opts.SoftDelete = true
+		opts.SoftDeleteWindow = duration
+	}
+	for _, prefix := range d.Get("protected_prefixes").([]interface{}) {
+		opts.ProtectedPrefixes = append(opts.ProtectedPrefixes, prefix.(string))
+	}
+	opts.TrackAccess = d.Get("track_access").(bool)
+
 	var sess *session.Session
 	var err error
 	if profile != defaultAWSProfile {
@@ -73,8 +132,10 @@ func providerConfig(ctx context.Context, d *schema.ResourceData) (interface{}, d
 		return nil, diag.FromErr(err)
 	}
 	tflog.Debug(ctx, "Creating Credstash Client", map[string]interface{}{
-		"table": table,
+		"table":                  table,
+		"superseded_version_ttl": opts.SupersededVersionTTL.String(),
+		"soft_delete_window":     opts.SoftDeleteWindow.String(),
 	})
 
-	return credstash.New(table, sess), nil
+	return credstash.NewWithOptions(table, sess, opts), nil
 }
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// KeyTransforms lists the supported key name transforms
+var KeyTransforms = []string{"none", "upper_snake"}
+
+// TransformKeys removes prefix from the start of every name and applies the key transform.
+// It fails when two names end up with the same key.
+func TransformKeys(secrets map[string]string, prefix string, transform string) (map[string]string, error) {
+	var convert func(string) string
+	switch transform {
+	case "", "none":
+		convert = func(s string) string { return s }
+	case "upper_snake":
+		convert = upperSnake
+	default:
+		return nil, fmt.Errorf("unknown key transform %q, expected one of %s", transform, strings.Join(KeyTransforms, ", "))
+	}
+
+	out := make(map[string]string, len(secrets))
+	from := make(map[string]string, len(secrets))
+	for _, name := range sortedKeys(secrets) {
+		key := convert(strings.TrimPrefix(name, prefix))
+		if key == "" {
+			return nil, fmt.Errorf("secret %q has an empty key after removing the prefix", name)
+		}
+		if other, ok := from[key]; ok {
+			return nil, fmt.Errorf("secrets %q and %q both render as key %q", other, name, key)
+		}
+		from[key] = name
+		out[key] = secrets[name]
+	}
+	return out, nil
+}
+
+// upperSnake converts names like `app.dbPassword` or `api-token` to `APP_DB_PASSWORD` and
+// `API_TOKEN`, so they can be used as environment variables
+func upperSnake(s string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		case b.Len() > 0 && prev != '_':
+			b.WriteRune('_')
+			r = '_'
+		default:
+			r = '_'
+		}
+		prev = r
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
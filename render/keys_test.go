@@ -0,0 +1,31 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformKeys(t *testing.T) {
+	out, err := TransformKeys(map[string]string{"app.dbPassword": "a", "app.api-token": "b"}, "app.", "upper_snake")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "a", "API_TOKEN": "b"}, out)
+
+	out, err = TransformKeys(map[string]string{"app.x": "a"}, "", "none")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"app.x": "a"}, out)
+}
+
+func TestTransformKeysCollision(t *testing.T) {
+	_, err := TransformKeys(map[string]string{"db.password": "a", "db_password": "b"}, "", "upper_snake")
+	assert.Error(t, err)
+
+	_, err = TransformKeys(map[string]string{"a": "a"}, "", "camel")
+	assert.Error(t, err)
+}
+
+func TestUpperSnake(t *testing.T) {
+	assert.Equal(t, "APP_DB_PASSWORD", upperSnake("app.dbPassword"))
+	assert.Equal(t, "OAUTH2_CLIENT_ID", upperSnake("oauth2ClientID"))
+	assert.Equal(t, "A_B", upperSnake("--a..b--"))
+}
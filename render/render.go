@@ -0,0 +1,184 @@
+// Package render formats a set of secrets the way the credstash CLI prints them.
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{"json", "yaml", "csv", "dotenv", "properties"}
+
+// Render formats secrets keyed by name in the given format
+func Render(secrets map[string]string, format string) (string, error) {
+	switch format {
+	case "json":
+		return renderJSON(secrets)
+	case "yaml":
+		return renderYAML(secrets), nil
+	case "csv":
+		return renderCSV(secrets)
+	case "dotenv":
+		return renderDotenv(secrets)
+	case "properties":
+		return renderProperties(secrets), nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func sortedKeys(secrets map[string]string) []string {
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderJSON matches json.dumps(secrets, indent=4, sort_keys=True)
+func renderJSON(secrets map[string]string) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(secrets); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var (
+	// Scalars starting with a digit, '.', '+' or '-' are always quoted, YAML 1.1 and 1.2 read
+	// many of them as numbers, like .5, 1e3, 0x1F, 0o17 or 1_000
+	yamlPlain    = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./ -]*$`)
+	yamlReserved = regexp.MustCompile(`^(?i:y|n|yes|no|on|off|true|false|null)$`)
+)
+
+// yamlString quotes a scalar unless YAML would read it back unchanged as a plain string
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !yamlReserved.MatchString(s) && !strings.HasSuffix(s, " ") {
+		return s
+	}
+	// JSON string escapes are valid in double quoted YAML scalars
+	return jsonQuote(s)
+}
+
+// jsonQuote returns s as a JSON string literal without HTML escaping
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func renderYAML(secrets map[string]string) string {
+	if len(secrets) == 0 {
+		return "{}\n"
+	}
+	var b strings.Builder
+	for _, k := range sortedKeys(secrets) {
+		fmt.Fprintf(&b, "%s: %s\n", yamlString(k), yamlString(secrets[k]))
+	}
+	return b.String()
+}
+
+func renderCSV(secrets map[string]string) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, k := range sortedKeys(secrets) {
+		if err := w.Write([]string{k, secrets[k]}); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+var shellSafe = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+// shellQuote matches Python's shlex.quote
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+var envInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// envName upper cases a name and replaces the characters a shell variable name cannot hold
+// with '_', names starting with a digit get a leading '_'
+func envName(s string) string {
+	name := envInvalid.ReplaceAllString(strings.ToUpper(s), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// renderDotenv writes upper cased variable names with shell quoted values. It fails when two
+// names end up as the same variable.
+func renderDotenv(secrets map[string]string) (string, error) {
+	var b strings.Builder
+	from := make(map[string]string, len(secrets))
+	for _, k := range sortedKeys(secrets) {
+		name := envName(k)
+		if other, ok := from[name]; ok {
+			return "", fmt.Errorf("secrets %q and %q both render as variable %s", other, k, name)
+		}
+		from[name] = k
+		fmt.Fprintf(&b, "%s=%s\n", name, shellQuote(secrets[k]))
+	}
+	return b.String(), nil
+}
+
+// propertiesEscape escapes a Java properties key or value like Properties.store does. Spaces
+// are escaped everywhere in keys and only at the start of values. Characters outside printable ASCII are written
+// as \uXXXX, so the output loads with the ISO-8859-1 default of Properties.load.
+func propertiesEscape(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == ' ' && (key || i == 0):
+			b.WriteString(`\ `)
+		case strings.ContainsRune("=:#!", r):
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04x`, unit)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func renderProperties(secrets map[string]string) string {
+	var b strings.Builder
+	for _, k := range sortedKeys(secrets) {
+		fmt.Fprintf(&b, "%s=%s\n", propertiesEscape(k, true), propertiesEscape(secrets[k], false))
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var secrets = map[string]string{
+	"db.password": "it's <secret>",
+	"api_token":   "abc123",
+	"port":        "5432",
+}
+
+func TestRenderJSON(t *testing.T) {
+	out, err := Render(secrets, "json")
+	assert.Nil(t, err)
+	assert.Equal(t, "{\n    \"api_token\": \"abc123\",\n    \"db.password\": \"it's <secret>\",\n    \"port\": \"5432\"\n}\n", out)
+}
+
+func TestRenderYAML(t *testing.T) {
+	out, err := Render(secrets, "yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "api_token: abc123\ndb.password: \"it's <secret>\"\nport: \"5432\"\n", out)
+}
+
+func TestRenderYAMLQuotesNumbers(t *testing.T) {
+	for _, s := range []string{".5", "1e3", "0x1F", "0o17", "1_000", "+1", "-1", ".inf", "12:30", "Yes"} {
+		assert.Equal(t, jsonQuote(s), yamlString(s), s)
+	}
+	for _, s := range []string{"abc123", "api_token", "/etc/app", "e10"} {
+		assert.Equal(t, s, yamlString(s), s)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	out, err := Render(map[string]string{"a": "x,y", "b": "plain"}, "csv")
+	assert.Nil(t, err)
+	assert.Equal(t, "a,\"x,y\"\nb,plain\n", out)
+}
+
+func TestRenderDotenv(t *testing.T) {
+	out, err := Render(secrets, "dotenv")
+	assert.Nil(t, err)
+	assert.Equal(t, "API_TOKEN=abc123\nDB_PASSWORD='it'\"'\"'s <secret>'\nPORT=5432\n", out)
+
+	out, err = Render(map[string]string{"1st-key": "x"}, "dotenv")
+	assert.Nil(t, err)
+	assert.Equal(t, "_1ST_KEY=x\n", out)
+
+	_, err = Render(map[string]string{"db.password": "a", "db-password": "b"}, "dotenv")
+	assert.Error(t, err)
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	_, err := Render(secrets, "toml")
+	assert.Error(t, err)
+}
+
+func TestRenderProperties(t *testing.T) {
+	out, err := Render(map[string]string{"db url": "jdbc:pg://h/db", "greeting": " héllo\nworld"}, "properties")
+	assert.Nil(t, err)
+	assert.Equal(t, "db\\ url=jdbc\\:pg\\://h/db\ngreeting=\\ h\\u00e9llo\\nworld\n", out)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceKeyPair() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceKeyPairCreate,
+		ReadContext:   resourceKeyPairRead,
+		DeleteContext: resourceKeyPairDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceKeyPairStateImporter,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "name of the secret holding the PEM private key",
+			},
+			"table": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "name of DynamoDB table where the secrets are stored",
+				Default:     "",
+			},
+			"context": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "encryption context for the secret",
+			},
+			"algorithm": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(credstash.KeyAlgorithms, false),
+				Description:  "The key algorithm: `RSA`, `ECDSA` or `ED25519`.",
+			},
+			"rsa_bits": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      4096,
+				ValidateFunc: validation.IntAtLeast(2048),
+				Description:  "The size of RSA keys.",
+			},
+			"ecdsa_curve": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "P256",
+				ValidateFunc: validation.StringInSlice([]string{"P256", "P384", "P521"}, false),
+				Description:  "The curve of ECDSA keys: `P256`, `P384` or `P521`.",
+			},
+			"version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The version of the secret holding the private key.",
+			},
+			"public_key_pem": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The public key in PEM (PKIX) format.",
+			},
+			"public_key_openssh": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The public key in OpenSSH authorized_keys format.",
+			},
+			"public_key_fingerprint_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SHA256 fingerprint of the public key, as printed by `ssh-keygen -l`.",
+			},
+			"public_key_fingerprint_md5": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The legacy MD5 fingerprint of the public key, as printed by `ssh-keygen -l -E md5`.",
+			},
+		},
+	}
+}
+
+func resourceKeyPairCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*credstash.Client)
+
+	name := d.Get("name").(string)
+	table := d.Get("table").(string)
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
+
+	tflog.Debug(ctx, "resourceKeyPairCreate generating key", map[string]interface{}{
+		"name":      name,
+		"table":     table,
+		"algorithm": d.Get("algorithm").(string),
+	})
+
+	privateKey, err := client.GenerateKeyPair(credstash.KeyPairOptions{
+		Algorithm:  d.Get("algorithm").(string),
+		RSABits:    d.Get("rsa_bits").(int),
+		ECDSACurve: d.Get("ecdsa_curve").(string),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Never overwrite a version written by someone else
+	paddedVersion, err := client.ResolveVersion(table, name, 0)
+	if err != nil {
+		return secretErrorDiags(err)
+	}
+	err = client.PutSecret(table, name, privateKey, paddedVersion, context)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("version", versionNumber(paddedVersion))
+	d.SetId(secretID(client.TableName(table), name))
+	return resourceKeyPairRead(ctx, d, m)
+}
+
+func resourceKeyPairRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Refreshing the public key is not a use of the secret
+	client := m.(*credstash.Client).WithoutAccessTracking()
+
+	name := d.Get("name").(string)
+	table := d.Get("table").(string)
+	version := d.Get("version").(int)
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
+
+	secret, err := client.GetSecret(name, table, client.PaddedInt(version), context)
+	if errors.Is(err, credstash.ErrSecretNotFound) && !d.IsNewResource() {
+		// Dropping it from state lets the next plan generate a new key pair
+		d.SetId("")
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Key pair deleted outside of Terraform",
+			Detail:   fmt.Sprintf("%s. It has been removed from the state and a new key pair will be generated on the next apply.", err.Error()),
+		}}
+	}
+	if err != nil {
+		return secretErrorDiags(err)
+	}
+
+	public, err := credstash.PublicKey(secret.Secret)
+	if err != nil {
+		return errorDiag("Invalid private key", fmt.Sprintf("Secret %q version %d does not hold a PEM private key: %s.", name, version, err))
+	}
+
+	d.Set("public_key_pem", public.PEM)
+	d.Set("public_key_openssh", public.OpenSSH)
+	d.Set("public_key_fingerprint_sha256", public.FingerprintSHA256)
+	d.Set("public_key_fingerprint_md5", public.FingerprintMD5)
+	return nil
+}
+
+func resourceKeyPairDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*credstash.Client)
+
+	// Only the version holding this key pair is removed, other writers may share the name
+	version := client.PaddedInt(d.Get("version").(int))
+	err := client.DeleteSecretVersion(d.Get("table").(string), d.Get("name").(string), version)
+	if err != nil {
+		return secretErrorDiags(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceKeyPairStateImporter imports the latest version of a secret holding a PEM private key,
+// taking the IDs of credstash_secret. The algorithm and key size are read from the key, the
+// secret must have no encryption context.
+func resourceKeyPairStateImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	client := m.(*credstash.Client).WithoutAccessTracking()
+
+	table, name, err := importSecretID(client, d.Id())
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.GetHighestVersionSecret(table, name, credstash.NewEncryptionContextValue())
+	if err != nil {
+		return nil, err
+	}
+	public, err := credstash.PublicKey(secret.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("secret %q does not hold a PEM private key: %w", name, err)
+	}
+
+	// Settings that do not apply to the algorithm keep their defaults so the plan shows no diff
+	rsaBits, ecdsaCurve := 4096, "P256"
+	if public.RSABits != 0 {
+		rsaBits = public.RSABits
+	}
+	if public.ECDSACurve != "" {
+		ecdsaCurve = public.ECDSACurve
+	}
+	d.Set("name", name)
+	d.Set("table", table)
+	d.Set("version", versionNumber(secret.Version))
+	d.Set("algorithm", public.Algorithm)
+	d.Set("rsa_bits", rsaBits)
+	d.Set("ecdsa_curve", ecdsaCurve)
+	d.SetId(secretID(client.TableName(table), name))
+	return []*schema.ResourceData{d}, nil
+}
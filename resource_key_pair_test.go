@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestKeyPairCreateAndDelete(t *testing.T) {
+	client, db := newTestClient(credstash.Options{})
+	ctx := credstash.NewEncryptionContextValue()
+	if err := client.PutSecret("", "deploy.ssh_key", "written by someone else", client.PaddedInt(1), ctx); err != nil {
+		t.Fatal(err)
+	}
+	d := schema.TestResourceDataRaw(t, resourceKeyPair().Schema, map[string]interface{}{
+		"name":      "deploy.ssh_key",
+		"algorithm": credstash.KeyAlgorithmEd25519,
+	})
+
+	if diags := resourceKeyPairCreate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("create failed: %#v", diags)
+	}
+	if version := d.Get("version").(int); version != 2 {
+		t.Fatalf("version = %d, expected the key pair to be written after version 1", version)
+	}
+	if d.Id() != "credential-store/deploy.ssh_key" || d.Get("public_key_openssh").(string) == "" {
+		t.Fatalf("unexpected state after create: ID %q, public key %q", d.Id(), d.Get("public_key_openssh"))
+	}
+
+	if diags := resourceKeyPairDelete(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("delete failed: %#v", diags)
+	}
+	if db.Item("credential-store", "deploy.ssh_key", client.PaddedInt(2)) != nil {
+		t.Fatal("expected the version holding the key pair to be deleted")
+	}
+	if db.Item("credential-store", "deploy.ssh_key", client.PaddedInt(1)) == nil {
+		t.Fatal("expected the version written by someone else to be kept")
+	}
+}
+
+func TestReadKeyPairDeletedOutsideTerraform(t *testing.T) {
+	client, _ := newTestClient(credstash.Options{})
+	d := schema.TestResourceDataRaw(t, resourceKeyPair().Schema, map[string]interface{}{
+		"name":      "deploy.ssh_key",
+		"algorithm": credstash.KeyAlgorithmEd25519,
+	})
+	d.SetId("credential-store/deploy.ssh_key")
+	d.Set("version", 1)
+
+	diags := resourceKeyPairRead(context.Background(), d, client)
+
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning || diags[0].Summary != "Key pair deleted outside of Terraform" {
+		t.Fatalf("expected a deleted outside of Terraform warning, got %#v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected the key pair to be removed from state, got ID %q", d.Id())
+	}
+}
+
+func TestImportKeyPair(t *testing.T) {
+	client, _ := newTestClient(credstash.Options{})
+	ctx := credstash.NewEncryptionContextValue()
+	cases := []struct {
+		opts       credstash.KeyPairOptions
+		rsaBits    int
+		ecdsaCurve string
+	}{
+		{opts: credstash.KeyPairOptions{Algorithm: credstash.KeyAlgorithmRSA, RSABits: 2048}, rsaBits: 2048, ecdsaCurve: "P256"},
+		{opts: credstash.KeyPairOptions{Algorithm: credstash.KeyAlgorithmECDSA, ECDSACurve: "P384"}, rsaBits: 4096, ecdsaCurve: "P384"},
+		{opts: credstash.KeyPairOptions{Algorithm: credstash.KeyAlgorithmEd25519}, rsaBits: 4096, ecdsaCurve: "P256"},
+	}
+	for i, tc := range cases {
+		private, err := client.GenerateKeyPair(tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.PutSecret("", "key", private, client.PaddedInt(i+1), ctx); err != nil {
+			t.Fatal(err)
+		}
+		d := resourceKeyPair().TestResourceData()
+		d.SetId("key")
+
+		if _, err := resourceKeyPairStateImporter(context.Background(), d, client); err != nil {
+			t.Fatalf("import %s: %s", tc.opts.Algorithm, err)
+		}
+
+		if d.Get("algorithm").(string) != tc.opts.Algorithm || d.Get("version").(int) != i+1 {
+			t.Fatalf("import %s read algorithm %q and version %d", tc.opts.Algorithm, d.Get("algorithm"), d.Get("version"))
+		}
+		if d.Get("rsa_bits").(int) != tc.rsaBits || d.Get("ecdsa_curve").(string) != tc.ecdsaCurve {
+			t.Fatalf("import %s read rsa_bits %d and ecdsa_curve %q, expected %d and %q",
+				tc.opts.Algorithm, d.Get("rsa_bits"), d.Get("ecdsa_curve"), tc.rsaBits, tc.ecdsaCurve)
+		}
+		if d.Id() != "credential-store/key" {
+			t.Fatalf("import %s set ID %q", tc.opts.Algorithm, d.Id())
+		}
+	}
+
+	if err := client.PutSecret("", "not-a-key", "hunter2", client.PaddedInt(1), ctx); err != nil {
+		t.Fatal(err)
+	}
+	d := resourceKeyPair().TestResourceData()
+	d.SetId("not-a-key")
+	if _, err := resourceKeyPairStateImporter(context.Background(), d, client); err == nil {
+		t.Fatal("expected importing a secret without a private key to fail")
+	}
+}
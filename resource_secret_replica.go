@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceSecretReplica() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSecretReplicaCreate,
+		ReadContext:   resourceSecretReplicaRead,
+		UpdateContext: resourceSecretReplicaUpdate,
+		DeleteContext: resourceSecretReplicaDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "name of the secret, the same in the source and the destination",
+			},
+			"version": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "version of the source secret to replicate, 0 follows the latest version",
+			},
+			"source_table": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "name of DynamoDB table the secret is read from",
+			},
+			"source_region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "region of the source table, defaults to the provider region",
+			},
+			"source_context": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "encryption context of the source secret",
+			},
+			"destination_table": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "",
+				Description: "name of DynamoDB table the secret is written to",
+			},
+			"destination_region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "",
+				Description: "region of the destination table, defaults to the provider region",
+			},
+			"kms_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     credstash.DefaultKmsKey,
+				Description: "KMS key in the destination region used to wrap the copy",
+			},
+			"context": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "encryption context of the copy in the destination",
+			},
+			"source_version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The source version that was last replicated.",
+			},
+			"destination_version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The destination version written by the last replication.",
+			},
+			"destination_versions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "Every destination version written by this resource, the versions removed on destroy.",
+			},
+		},
+		CustomizeDiff: resourceSecretReplicaCustomizeDiff,
+	}
+}
+
+// replicaClients returns the clients of the source and destination regions
+func replicaClients(d resourceGetter, m interface{}) (*credstash.Client, *credstash.Client) {
+	client := m.(*credstash.Client)
+	return client.ForRegion(d.Get("source_region").(string)), client.ForRegion(d.Get("destination_region").(string))
+}
+
+// resourceGetter is implemented by both schema.ResourceData and schema.ResourceDiff
+type resourceGetter interface {
+	Get(key string) interface{}
+}
+
+func resourceSecretReplicaCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if diags := replicateSecret(ctx, d, m); diags.HasError() {
+		return diags
+	}
+	_, dst := replicaClients(d, m)
+	d.SetId(secretID(dst.TableName(d.Get("destination_table").(string)), d.Get("name").(string)))
+	return resourceSecretReplicaRead(ctx, d, m)
+}
+
+// replicateSecret copies the source secret into the destination and records both versions
+func replicateSecret(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	src, dst := replicaClients(d, m)
+
+	name := d.Get("name").(string)
+	ref := credstash.SecretRef{
+		Table:   d.Get("source_table").(string),
+		Name:    name,
+		Context: encryptionContext(d.Get("source_context").(map[string]interface{})),
+	}
+	if version := d.Get("version").(int); version != 0 {
+		ref.Version = src.PaddedInt(version)
+	}
+
+	tflog.Debug(ctx, "replicateSecret copying secret", map[string]interface{}{
+		"name":               name,
+		"source_table":       ref.Table,
+		"source_region":      d.Get("source_region").(string),
+		"destination_table":  d.Get("destination_table").(string),
+		"destination_region": d.Get("destination_region").(string),
+	})
+
+	srcVersion, dstVersion, err := src.Copy(ref, dst, credstash.CopyOptions{
+		Table:   d.Get("destination_table").(string),
+		KmsKey:  d.Get("kms_key").(string),
+		Context: encryptionContext(d.Get("context").(map[string]interface{})),
+	})
+	if err != nil {
+		return secretErrorDiags(err)
+	}
+
+	d.Set("source_version", versionNumber(srcVersion))
+	d.Set("destination_version", versionNumber(dstVersion))
+	d.Set("destination_versions", append(d.Get("destination_versions").([]interface{}), versionNumber(dstVersion)))
+	return nil
+}
+
+func resourceSecretReplicaRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	_, dst := replicaClients(d, m)
+
+	name := d.Get("name").(string)
+	table := d.Get("destination_table").(string)
+
+	_, err := dst.GetLatestVersion(table, name)
+	if errors.Is(err, credstash.ErrSecretNotFound) && !d.IsNewResource() {
+		// The copy was removed outside of Terraform, replicate it again on the next apply
+		d.SetId("")
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Secret replica deleted outside of Terraform",
+			Detail:   fmt.Sprintf("%s. It has been removed from the state and will be replicated again on the next apply.", err.Error()),
+		}}
+	}
+	if err != nil {
+		return secretErrorDiags(err)
+	}
+	return nil
+}
+
+func resourceSecretReplicaUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if d.HasChanges("version", "source_table", "source_region", "source_context", "kms_key", "context", "source_version") {
+		if diags := replicateSecret(ctx, d, m); diags.HasError() {
+			return diags
+		}
+	}
+	return resourceSecretReplicaRead(ctx, d, m)
+}
+
+func resourceSecretReplicaDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	_, dst := replicaClients(d, m)
+
+	// Only the copies this resource wrote are removed, other writers may share the name
+	versions := d.Get("destination_versions").([]interface{})
+	if len(versions) == 0 {
+		versions = []interface{}{d.Get("destination_version")}
+	}
+	for _, version := range versions {
+		err := dst.DeleteSecretVersion(d.Get("destination_table").(string), d.Get("name").(string), dst.PaddedInt(version.(int)))
+		if err != nil {
+			return secretErrorDiags(err)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// checkReplicaDestination rejects a destination that is the source table itself, the copy
+// would become the next source version and the replica would chase itself
+func checkReplicaDestination(d *schema.ResourceDiff, src *credstash.Client, dst *credstash.Client) error {
+	for _, key := range []string{"source_table", "source_region", "destination_table", "destination_region"} {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
+	srcTable := src.TableName(d.Get("source_table").(string))
+	if srcTable == dst.TableName(d.Get("destination_table").(string)) && src.Region() == dst.Region() {
+		return fmt.Errorf("the destination is the source table %q in the same region, set destination_table or destination_region", srcTable)
+	}
+	return nil
+}
+
+// resourceSecretReplicaCustomizeDiff plans a new replication when the source has a version
+// newer than the one last replicated, so updates propagate on apply.
+func resourceSecretReplicaCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	src, dst := replicaClients(d, m)
+	if err := checkReplicaDestination(d, src, dst); err != nil {
+		return err
+	}
+	if d.Id() == "" || d.Get("version").(int) != 0 {
+		return nil
+	}
+
+	latestVersion, err := src.GetLatestVersion(d.Get("source_table").(string), d.Get("name").(string))
+	if errors.Is(err, credstash.ErrSecretNotFound) {
+		// Nothing to propagate while the source is missing, the replica is left as it is
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if latestVersion != d.Get("source_version").(int) {
+		tflog.Debug(ctx, "resourceSecretReplicaCustomizeDiff source changed", map[string]interface{}{
+			"source_version": latestVersion,
+		})
+		// Computed rather than latestVersion, another version may land before the apply
+		if err := d.SetNewComputed("source_version"); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("destination_version"); err != nil {
+			return err
+		}
+		return d.SetNewComputed("destination_versions")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestReplicaRejectsSourceAsDestination(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")}))
+	client := credstash.New("credential-store", sess)
+
+	cases := []struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		{config: map[string]interface{}{"name": "app"}, valid: false},
+		{config: map[string]interface{}{"name": "app", "destination_table": "credential-store", "source_region": "us-east-1"}, valid: false},
+		{config: map[string]interface{}{"name": "app", "destination_table": "replica"}, valid: true},
+		{config: map[string]interface{}{"name": "app", "destination_region": "eu-west-1"}, valid: true},
+	}
+	for _, tc := range cases {
+		_, err := resourceSecretReplica().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(tc.config), client)
+		if tc.valid && err != nil {
+			t.Fatalf("err with %v: %s", tc.config, err)
+		}
+		if !tc.valid && (err == nil || !strings.Contains(err.Error(), "same region")) {
+			t.Fatalf("expected the destination to be rejected with %v, got %v", tc.config, err)
+		}
+	}
+}
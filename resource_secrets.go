@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/granular-oss/terraform-provider-credstash/credstash"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceSecret() *schema.Resource {
@@ -18,6 +23,15 @@ func resourceSecret() *schema.Resource {
 		UpdateContext: resourceSecretUpdate,
 		DeleteContext: resourceSecretDelete,
 
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceSecretV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceSecretStateUpgradeV0,
+			},
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
@@ -46,21 +60,195 @@ func resourceSecret() *schema.Resource {
 				Computed:     true,
 				Optional:     true,
 				Sensitive:    true,
-				Description:  "The secret contents. Either `value` or `generate` must be defined.",
-				ExactlyOneOf: []string{"generate", "value"},
+				Description:  "The secret contents. Exactly one of `value`, `generate` or `source_file` must be defined.",
+				ExactlyOneOf: []string{"generate", "value", "source_file"},
+			},
+			"metadata": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Description: "Attributes like owner, rotation ticket or purpose stored unencrypted with the secret in DynamoDB, " +
+					"the credstash CLI ignores them. Changing them updates the managed version in place.",
+			},
+			"expires_at": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validation.IsRFC3339Time,
+				ConflictsWith: []string{"valid_for"},
+				Description: "The RFC 3339 time after which new versions are expired, stored on the item. " +
+					"Changing or removing it updates the managed version in place.",
+			},
+			"version_expires_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The RFC 3339 expiry stored on the managed version, from `expires_at` or `valid_for`.",
+			},
+			"valid_for": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description:  "How long every new version is valid, like `2160h` for 90 days. Sets `version_expires_at` when a version is written.",
+			},
+			"rotate_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				RequiredWith: []string{"valid_for"},
+				Description: "Plan a new generated version when the managed version expires within this duration, like `168h`. " +
+					"Only applies to `generate` when `version` is not pinned.",
+			},
+			"source_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "The path of a file to store as the secret, read at plan time. Only its SHA-256 is kept in state. Exactly one of `value`, `generate` or `source_file` must be defined.",
+				ExactlyOneOf: []string{"generate", "value", "source_file"},
+			},
+			"source_file_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The hex encoded SHA-256 of the stored secret when `source_file` is set. A new version is written when the file's hash differs.",
+			},
+			"latest_version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The highest version of the secret stored in the table.",
+			},
+			"expose_value_sha256": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to store the SHA-256 of the secret value in `value_sha256`.",
+			},
+			"value_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The hex encoded SHA-256 of the secret value, only set when `expose_value_sha256` is true.",
+			},
+			"entropy_bits": {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "The entropy in bits of the secrets generated by `generate`, so the impact of its constraints is visible in the plan. 0 for supplied values.",
+			},
+			"protected": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+				Description: "Whether destroying the secret fails, for secrets read by other systems. Set it to `false` and apply " +
+					"before destroying the secret. Defaults to whether the name matches the provider `protected_prefixes`.",
+			},
+			"drift_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      driftPolicyAdopt,
+				ValidateFunc: validation.StringInSlice([]string{driftPolicyAdopt, driftPolicyRestore, driftPolicyError}, false),
+				Description: "What to do when a newer version of the secret was written outside of Terraform. " +
+					"`adopt` takes the newer value into state, `restore` plans a new version with the managed value and " +
+					"`error` fails the refresh. Only applies when `version` is not pinned.",
+			},
+			"policy": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Requirements a supplied `value` must meet, checked at plan time without showing the value.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"min_length": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "The minimum number of characters.",
+						},
+						"max_length": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "The maximum number of characters.",
+						},
+						"require": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeInt},
+							Description: "The minimum number of characters from each character set, using the charset names of `generate`.",
+						},
+						"forbid_chars": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Characters the value must not contain.",
+						},
+						"min_entropy_bits": {
+							Type:     schema.TypeFloat,
+							Optional: true,
+							Description: "The minimum estimated entropy: the length times the log2 of the size of the character classes used. " +
+								"It is an upper bound, values made of words are much weaker than estimated.",
+						},
+					},
+				},
 			},
 			"generate": {
 				Type:         schema.TypeList,
 				Optional:     true,
 				MaxItems:     1,
-				Description:  "Settings for autogenerating a secret. Either `value` or `generate` must be defined.",
-				ExactlyOneOf: []string{"generate", "value"},
+				Description:  "Settings for autogenerating a secret. Exactly one of `value`, `generate` or `source_file` must be defined.",
+				ExactlyOneOf: []string{"generate", "value", "source_file"},
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          generateTypePassword,
+							ValidateFunc:     validation.StringInSlice(generateTypes, false),
+							Description:      "The kind of secret to generate: `password`, `passphrase`, `uuid`, `hex`, `base64`, `base64url` or `template`.",
+							DiffSuppressFunc: suppressNewDefault(generateTypePassword),
+						},
 						"length": {
 							Type:        schema.TypeInt,
-							Required:    true,
-							Description: "The length of the secret to generate.",
+							Optional:    true,
+							Description: "The length of the secret to generate. Required for passwords.",
+						},
+						"bytes": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "The number of random bytes to encode. Required for `hex`, `base64` and `base64url`.",
+						},
+						"template": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Description: "The format of the secret, like `sk_live_{alnum:24}`. Each `{class:n}` placeholder is replaced by n random " +
+								"characters of the class, `{{` and `}}` are literal braces. Required for `template`.",
+						},
+						"words": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          6,
+							Description:      "The number of words in a passphrase.",
+							DiffSuppressFunc: suppressNewDefault("6"),
+						},
+						"separator": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          "-",
+							Description:      "The separator between the words of a passphrase.",
+							DiffSuppressFunc: suppressNewDefault("-"),
+						},
+						"wordlist": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          credstash.DefaultWordlist,
+							ValidateFunc:     validation.StringInSlice([]string{"eff_large", "eff_short"}, false),
+							Description:      "The diceware wordlist passphrase words are picked from: `eff_large` or `eff_short`.",
+							DiffSuppressFunc: suppressNewDefault(credstash.DefaultWordlist),
+						},
+						"capitalize": {
+							Type:             schema.TypeBool,
+							Optional:         true,
+							Default:          false,
+							Description:      "Whether to capitalize every word of a passphrase.",
+							DiffSuppressFunc: suppressNewDefault("false"),
+						},
+						"include_number": {
+							Type:             schema.TypeBool,
+							Optional:         true,
+							Default:          false,
+							Description:      "Whether to append a random digit to one word of a passphrase.",
+							DiffSuppressFunc: suppressNewDefault("false"),
 						},
 						"use_symbols": {
 							Type:        schema.TypeBool,
@@ -80,6 +268,31 @@ func resourceSecret() *schema.Resource {
 							Elem:        &schema.Schema{Type: schema.TypeInt},
 							Description: "Ensure that the generated secret contains at least n characters from the given character set. Note that adding constraints reduces the strength of the secret.",
 						},
+						"custom_charset": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The printable ASCII characters to generate a password from, instead of `charsets` and `use_symbols`.",
+						},
+						"exclude_chars": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Characters the generated password must not contain, like quotes rejected by legacy systems.",
+						},
+						"seed": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Description: "The name of a secret in the same table and context to derive the value from, together with the name and version, " +
+								"so recreating the secret at the same version gives the same value. A secret recreated after a soft delete continues after the deleted " +
+								"versions and gets a new value. For reproducible test fixtures only, anyone able to read the seed can compute the value.",
+							ValidateDiagFunc: warnSeed,
+						},
+						"no_ambiguous": {
+							Type:             schema.TypeBool,
+							Optional:         true,
+							Default:          false,
+							Description:      "Whether to leave out characters that are easily mistaken for each other: `0O1lI`.",
+							DiffSuppressFunc: suppressNewDefault("false"),
+						},
 					},
 				},
 			},
@@ -87,9 +300,16 @@ func resourceSecret() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceSecretStateImporter,
 		},
+		CustomizeDiff: resourceSecretCustomizeDiff,
 	}
 }
 
+const (
+	driftPolicyAdopt   = "adopt"
+	driftPolicyRestore = "restore"
+	driftPolicyError   = "error"
+)
+
 func resourceSecretCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*credstash.Client)
 
@@ -101,70 +321,72 @@ func resourceSecretCreate(ctx context.Context, d *schema.ResourceData, m interfa
 	table := d.Get("table").(string)
 	value := d.Get("value").(string)
 	generateList := d.Get("generate").([]interface{})
-	if value == "" && len(generateList) == 0 {
-		return diag.FromErr(fmt.Errorf("either 'value' or 'generate' must be specified"))
+	sourceFile := d.Get("source_file").(string)
+	if value == "" && len(generateList) == 0 && sourceFile == "" {
+		return diag.FromErr(fmt.Errorf("one of 'value', 'generate' or 'source_file' must be specified"))
 	}
 
-	context := credstash.NewEncryptionContextValue()
-	for k, v := range d.Get("context").(map[string]interface{}) {
-		stringValue := fmt.Sprintf("%v", v)
-		(*context)[k] = &stringValue
-	}
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
 
+	// Soft deleted versions still hold their numbers, a new secret continues after them
+	paddedVersion, err := client.ResolveVersion(table, name, version)
+	if err != nil {
+		return secretErrorDiags(err)
+	}
 	if len(generateList) > 0 {
-		settings := generateList[0].(map[string]interface{})
-		useSymbols := settings["use_symbols"].(bool)
-		length := settings["length"].(int)
-		charsetSet := settings["charsets"].(*schema.Set)
-		minRuleMap := settings["min"].(map[string]interface{})
-		charsets := charsetSet.List()
-		var err error
-		value, err = client.GenerateRandomSecret(length, useSymbols, charsets, minRuleMap)
+		value, err = generateSecret(client, d, generateList[0].(map[string]interface{}), paddedVersion)
 		if err != nil {
 			return diag.FromErr(err)
 		}
+	} else if sourceFile != "" {
+		value, err = readSourceFile(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	} else if err := checkPolicy(d.Get("policy").([]interface{}), value); err != nil {
+		// The value may have been unknown at plan time
+		return diag.FromErr(err)
 	}
-	var paddedVersion string
-	if version == 0 {
-		paddedVersion = client.PaddedInt(1)
-	} else {
-		paddedVersion = client.PaddedInt(version)
-	}
-	err := client.PutSecret(table, name, value, paddedVersion, context)
+	expiresAt, err := secretExpiresAt(d, time.Now())
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("version", version)
+	err = client.PutSecretWithOptions(table, name, value, paddedVersion, context, credstash.PutOptions{
+		Metadata:  secretMetadata(d.Get("metadata").(map[string]interface{})),
+		ExpiresAt: expiresAt,
+		Pinned:    pinnedVersions(client, d),
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("value", string(value))
+	err = d.Set("version", version)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	d.SetId(hash(value))
+	if sourceFile == "" {
+		err = d.Set("value", string(value))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	// Record the version written here so Read does not mistake it for an outside change
+	d.Set("latest_version", versionNumber(paddedVersion))
+	d.SetId(secretID(client.TableName(table), name))
 	return resourceSecretRead(ctx, d, m)
 }
 
 func resourceSecretRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*credstash.Client)
+	// Refreshing the managed value is not a use of the secret
+	client := m.(*credstash.Client).WithoutAccessTracking()
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	name := d.Get("name").(string)
-	//Make Imports work, by reading ID if name is empty
-	if name == "" {
-		name = d.Id()
-	}
 	version := d.Get("version").(int)
 	table := d.Get("table").(string)
 
-	context := credstash.NewEncryptionContextValue()
-	for k, v := range d.Get("context").(map[string]interface{}) {
-		stringValue := fmt.Sprintf("%v", v)
-		(*context)[k] = &stringValue
-	}
+	context := encryptionContext(d.Get("context").(map[string]interface{}))
 
 	var value *credstash.DecryptedCredential
 	var err error
@@ -176,24 +398,103 @@ func resourceSecretRead(ctx context.Context, d *schema.ResourceData, m interface
 		"context": context,
 	})
 
+	knownVersion := d.Get("latest_version").(int)
+	latestVersion := 0
 	if version == 0 {
 		value, err = client.GetHighestVersionSecret(table, name, context)
+		if err == nil {
+			latestVersion = versionNumber(value.Version)
+		}
 	} else {
 		value, err = client.GetSecret(name, table, client.PaddedInt(version), context)
+		if err == nil {
+			latestVersion, err = client.GetLatestVersion(table, name)
+		}
 	}
 
+	if errors.Is(err, credstash.ErrSecretNotFound) && !d.IsNewResource() {
+		// The secret, or the pinned version of it, was removed outside of Terraform.
+		// Dropping it from state lets the next plan recreate it instead of failing.
+		tflog.Warn(ctx, "resourceSecretRead secret not found, removing from state", map[string]interface{}{
+			"name":    name,
+			"version": version,
+			"table":   table,
+		})
+		summary := "Secret deleted outside of Terraform"
+		if version != 0 {
+			summary = "Secret version deleted outside of Terraform"
+		}
+		d.SetId("")
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  summary,
+			Detail:   fmt.Sprintf("%s. It has been removed from the state and will be recreated on the next apply.", err.Error()),
+		})
+	}
 	if err != nil {
-		return diag.FromErr(err)
+		return secretErrorDiags(err)
+	}
+
+	if version == 0 && knownVersion != 0 && latestVersion > knownVersion {
+		drift := fmt.Sprintf("Secret %q has version %d in table %q, newer than version %d managed by Terraform.",
+			name, latestVersion, table, knownVersion)
+		switch d.Get("drift_policy").(string) {
+		case driftPolicyError:
+			return errorDiag("Secret changed outside of Terraform", drift+
+				" Set `drift_policy` to `adopt` or `restore` to resolve it.")
+		case driftPolicyRestore:
+			// Keep the managed version in state, CustomizeDiff plans a new version to restore it
+			value, err = client.GetSecret(name, table, client.PaddedInt(knownVersion), context)
+			if err != nil {
+				return secretErrorDiags(err)
+			}
+			latestVersion = knownVersion
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Secret changed outside of Terraform",
+				Detail:   drift + " The managed value will be written as a new version on the next apply.",
+			})
+		default:
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Secret changed outside of Terraform",
+				Detail:   drift + " The newer value has been adopted into state.",
+			})
+		}
 	}
 
-	d.SetId(hash(value.Secret))
-	d.Set("value", value.Secret)
+	d.SetId(secretID(client.TableName(table), name))
+	if d.Get("source_file").(string) != "" {
+		// Only the hash of file contents is kept, a differing hash plans a new version
+		d.Set("value", "")
+		d.Set("source_file_sha256", hash(value.Secret))
+	} else {
+		d.Set("value", value.Secret)
+		d.Set("source_file_sha256", "")
+	}
+	if d.Get("expose_value_sha256").(bool) {
+		d.Set("value_sha256", hash(value.Secret))
+	} else {
+		d.Set("value_sha256", "")
+	}
 	d.Set("table", table)
 	d.Set("version", version)
 	d.Set("name", name)
+	d.Set("latest_version", latestVersion)
+	d.Set("metadata", value.Metadata)
+	d.Set("version_expires_at", formatTimestamp(value.ExpiresAt))
+	if version != 0 && value.TTL != 0 {
+		// Another writer superseded the pinned version while superseded_version_ttl is set
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Pinned secret version expires",
+			Detail: fmt.Sprintf("Version %d of secret %q is superseded and DynamoDB TTL deletes it at %s. "+
+				"Pinning an old version is incompatible with superseded_version_ttl.", version, name, formatTimestamp(value.TTL)),
+		})
+	}
 
 	generateList := d.Get("generate").([]interface{})
-	if len(generateList) > 0 {
+	if len(generateList) > 0 && generateType(generateList[0].(map[string]interface{})) == generateTypePassword {
 		tflog.Warn(ctx, "setting generate length")
 		settings := generateList[0].(map[string]interface{})
 		settings["length"] = len(value.Secret)
@@ -201,13 +502,12 @@ func resourceSecretRead(ctx context.Context, d *schema.ResourceData, m interface
 	} else {
 		d.Set("generate", d.Get("generate"))
 	}
+	d.Set("entropy_bits", entropyBits(d.Get("generate").([]interface{})))
 
 	return diags
 }
 
 func resourceSecretDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*credstash.Client)
-
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
@@ -215,9 +515,17 @@ func resourceSecretDelete(ctx context.Context, d *schema.ResourceData, m interfa
 	name := d.Get("name").(string)
 	table := d.Get("table").(string)
 
+	// The state is checked, so unprotecting takes an apply of its own before the destroy
+	if d.Get("protected").(bool) {
+		return errorDiag("Secret is protected", fmt.Sprintf("Secret %q is protected from deletion. "+
+			"Set protected = false and apply, then destroy it in a separate apply.", name))
+	}
+
+	c := m.(*credstash.Client)
+
 	err := c.DeleteSecret(table, name)
 	if err != nil {
-		return diag.FromErr(err)
+		return secretErrorDiags(err)
 	}
 
 	// d.SetId("") is automatically called assuming delete returns no errors, but
@@ -230,7 +538,7 @@ func resourceSecretDelete(ctx context.Context, d *schema.ResourceData, m interfa
 func resourceSecretUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*credstash.Client)
 
-	if d.HasChange("value") || d.HasChange("generate") || d.HasChange("version") {
+	if hasGenerateChange(d) || d.HasChanges("value", "source_file", "source_file_sha256", "version", "latest_version") {
 
 		name := d.Get("name").(string)
 		table := d.Get("table").(string)
@@ -238,41 +546,51 @@ func resourceSecretUpdate(ctx context.Context, d *schema.ResourceData, m interfa
 		version := d.Get("version").(int)
 
 		generateList := d.Get("generate").([]interface{})
-		if value == "" && len(generateList) == 0 {
-			return diag.FromErr(fmt.Errorf("either 'value' or 'generate' must be specified"))
+		sourceFile := d.Get("source_file").(string)
+		if value == "" && len(generateList) == 0 && sourceFile == "" {
+			return diag.FromErr(fmt.Errorf("one of 'value', 'generate' or 'source_file' must be specified"))
 		}
 
-		context := credstash.NewEncryptionContextValue()
-		for k, v := range d.Get("context").(map[string]interface{}) {
-			stringValue := fmt.Sprintf("%v", v)
-			(*context)[k] = &stringValue
-		}
+		context := encryptionContext(d.Get("context").(map[string]interface{}))
 
 		paddedVersion, err := c.ResolveVersion(table, name, version)
 
 		if err != nil {
-			return diag.FromErr(err)
+			return secretErrorDiags(err)
 		}
 
-		if len(generateList) > 0 {
-			settings := generateList[0].(map[string]interface{})
-			useSymbols := settings["use_symbols"].(bool)
-			length := settings["length"].(int)
-			charsetSet := settings["charsets"].(*schema.Set)
-			minRuleMap := settings["min"].(map[string]interface{})
-			charsets := charsetSet.List()
+		// A restore only changes latest_version and writes the managed value again
+		if len(generateList) > 0 && (hasGenerateChange(d) || d.HasChange("version") || value == "") {
 			var err error
-			value, err = c.GenerateRandomSecret(length, useSymbols, charsets, minRuleMap)
+			value, err = generateSecret(c, d, generateList[0].(map[string]interface{}), paddedVersion)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		} else if sourceFile != "" {
+			value, err = readSourceFile(d)
 			if err != nil {
 				return diag.FromErr(err)
 			}
+		} else if len(generateList) == 0 {
+			if err := checkPolicy(d.Get("policy").([]interface{}), value); err != nil {
+				return diag.FromErr(err)
+			}
 		}
 
-		err = c.PutSecret(table, name, value, paddedVersion, context)
+		expiresAt, err := secretExpiresAt(d, time.Now())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		err = c.PutSecretWithOptions(table, name, value, paddedVersion, context, credstash.PutOptions{
+			Metadata:  secretMetadata(d.Get("metadata").(map[string]interface{})),
+			ExpiresAt: expiresAt,
+			Pinned:    pinnedVersions(c, d),
+		})
 
 		if err != nil {
 			return diag.FromErr(err)
 		}
+		d.Set("latest_version", versionNumber(paddedVersion))
 
 		//Update the secret version if we are not storing 0.
 		// if version != 0 {
@@ -284,15 +602,209 @@ func resourceSecretUpdate(ctx context.Context, d *schema.ResourceData, m interfa
 		// }
 
 		d.Set("last_updated", time.Now().Format(time.RFC850))
+	} else if d.HasChanges("metadata", "expires_at") {
+		// Neither is part of the secret, rewrite them on the managed version
+		table := d.Get("table").(string)
+		name := d.Get("name").(string)
+		version := d.Get("version").(int)
+		if version == 0 {
+			version = d.Get("latest_version").(int)
+		}
+		if d.HasChange("metadata") {
+			err := c.UpdateMetadata(table, name, c.PaddedInt(version), secretMetadata(d.Get("metadata").(map[string]interface{})))
+			if err != nil {
+				return secretErrorDiags(err)
+			}
+		}
+		if d.HasChange("expires_at") {
+			expiresAt, err := secretExpiresAt(d, time.Now())
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			if err := c.UpdateExpiry(table, name, c.PaddedInt(version), expiresAt); err != nil {
+				return secretErrorDiags(err)
+			}
+		}
 	}
 
 	return resourceSecretRead(ctx, d, m)
 }
 
+// resourceSecretCustomizeDiff validates the generate settings and the policy, plans entropy_bits and the hash
+// of source_file, and plans new versions restoring drift or rotating expiring secrets.
+func resourceSecretCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if generateList := d.Get("generate").([]interface{}); len(generateList) > 0 && generateList[0] != nil && d.NewValueKnown("generate") {
+		if err := validateGenerate(generateList[0].(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("generate") {
+		if err := d.SetNewComputed("entropy_bits"); err != nil {
+			return err
+		}
+	} else if bits := entropyBits(d.Get("generate").([]interface{})); bits != d.Get("entropy_bits").(float64) {
+		if err := d.SetNew("entropy_bits", bits); err != nil {
+			return err
+		}
+	}
+
+	if err := planSourceFile(d); err != nil {
+		return err
+	}
+
+	// Only supplied values are checked, a state value left from a generate block is not
+	if len(d.Get("generate").([]interface{})) == 0 && d.Get("source_file").(string) == "" &&
+		d.NewValueKnown("value") && d.NewValueKnown("policy") {
+		if err := checkPolicy(d.Get("policy").([]interface{}), d.Get("value").(string)); err != nil {
+			return err
+		}
+	}
+
+	if err := planProtected(d, m); err != nil {
+		return err
+	}
+
+	if err := planDriftRestore(ctx, d, m); err != nil {
+		return err
+	}
+	return planExpiry(d, time.Now())
+}
+
+// planProtected protects secrets matching the provider protected_prefixes when protected is
+// neither configured nor in state
+func planProtected(d *schema.ResourceDiff, m interface{}) error {
+	if _, ok := d.GetOkExists("protected"); ok {
+		return nil
+	}
+	if client, ok := m.(*credstash.Client); ok && client.Protected(d.Get("name").(string)) {
+		return d.SetNew("protected", true)
+	}
+	return nil
+}
+
+// planDriftRestore plans a new version when drift_policy is restore and another writer pushed a
+// version newer than the one managed by Terraform
+func planDriftRestore(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() == "" || d.Get("drift_policy").(string) != driftPolicyRestore || d.Get("version").(int) != 0 {
+		return nil
+	}
+	client := m.(*credstash.Client)
+
+	latestVersion, err := client.GetLatestVersion(d.Get("table").(string), d.Get("name").(string))
+	if errors.Is(err, credstash.ErrSecretNotFound) {
+		// Read removes deleted secrets from state, nothing to restore on top of
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if latestVersion > d.Get("latest_version").(int) {
+		tflog.Debug(ctx, "resourceSecretCustomizeDiff restoring managed version", map[string]interface{}{
+			"latest_version": latestVersion,
+		})
+		return d.SetNewComputed("latest_version")
+	}
+	return nil
+}
+
+// secretMetadata converts a Terraform metadata map into the metadata stored with a secret
+func secretMetadata(raw map[string]interface{}) map[string]string {
+	metadata := make(map[string]string, len(raw))
+	for k, v := range raw {
+		metadata[k] = v.(string)
+	}
+	return metadata
+}
+
+// versionNumber converts a zero padded credstash version into a number, returning 0 for
+// versions that are not numeric
+func versionNumber(paddedVersion string) int {
+	v, err := strconv.Atoi(paddedVersion)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// pinnedVersions returns the version the resource pins, which superseded_version_ttl must not
+// expire
+func pinnedVersions(c *credstash.Client, d *schema.ResourceData) []string {
+	if version := d.Get("version").(int); version != 0 {
+		return []string{c.PaddedInt(version)}
+	}
+	return nil
+}
+
+// hasGenerateChange reports whether the generate block changed. HasChange cannot be used, it
+// compares the charsets sets including their hash function and always reports a change.
+func hasGenerateChange(d interface {
+	GetChange(string) (interface{}, interface{})
+}) bool {
+	old, new := d.GetChange("generate")
+	return !reflect.DeepEqual(comparableGenerate(old), comparableGenerate(new))
+}
+
+// comparableGenerate replaces the sets of a generate list by their elements
+func comparableGenerate(raw interface{}) []map[string]interface{} {
+	var list []map[string]interface{}
+	for _, item := range raw.([]interface{}) {
+		settings, _ := item.(map[string]interface{})
+		copied := make(map[string]interface{}, len(settings))
+		for k, v := range settings {
+			if set, ok := v.(*schema.Set); ok {
+				v = set.List()
+			}
+			copied[k] = v
+		}
+		list = append(list, copied)
+	}
+	return list
+}
+
+// secretID builds the resource ID of a secret, which stays stable across versions and values
+func secretID(table string, name string) string {
+	return table + "/" + name
+}
+
+// resourceSecretStateImporter accepts the IDs described at importSecretID
 func resourceSecretStateImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	client := m.(*credstash.Client)
+
+	table, name, err := importSecretID(client, d.Id())
+	if err != nil {
+		return nil, err
+	}
+	d.Set("name", name)
+	d.Set("table", table)
+	d.SetId(secretID(client.TableName(table), name))
+
 	generateSettings := map[string]interface{}{
+		"type":        generateTypePassword,
 		"use_symbols": true,
 	}
 	d.Set("generate", append(make([]interface{}, 0), generateSettings))
 	return []*schema.ResourceData{d}, nil
 }
+
+// importSecretID splits an import ID into a table and a name. The ID is a name in the
+// provider's default table, or table:name. Names may contain both '/' and ':', so an ID naming
+// a secret of the default table always imports that secret. DynamoDB table names cannot
+// contain ':'.
+func importSecretID(client *credstash.Client, id string) (string, string, error) {
+	table, name := "", id
+	if parts := strings.SplitN(id, ":", 2); len(parts) == 2 {
+		_, err := client.GetLatestVersion("", id)
+		if errors.Is(err, credstash.ErrSecretNotFound) {
+			table, name = parts[0], parts[1]
+		} else if err != nil {
+			return "", "", err
+		}
+	}
+	if table == client.TableName("") {
+		// Keep the default table implicit so configurations without `table` do not show a diff
+		table = ""
+	}
+	return table, name, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// validateDuration checks a Go duration like `720h`
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q must be a duration like 720h: %w", k, err)}
+	}
+	return nil, nil
+}
+
+// secretExpiresAt returns the Unix expiry of a version written now: now plus valid_for, the
+// configured expires_at, or 0 when the secret does not expire. expires_at is not computed, so an
+// expiry read from the managed version is never carried over to a new one.
+func secretExpiresAt(d *schema.ResourceData, now time.Time) (int64, error) {
+	if validFor := d.Get("valid_for").(string); validFor != "" {
+		duration, err := time.ParseDuration(validFor)
+		if err != nil {
+			return 0, fmt.Errorf("valid_for: %w", err)
+		}
+		return now.Add(duration).Unix(), nil
+	}
+	if expiresAt := d.Get("expires_at").(string); expiresAt != "" {
+		expiry, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return 0, fmt.Errorf("expires_at: %w", err)
+		}
+		return expiry.Unix(), nil
+	}
+	return 0, nil
+}
+
+// formatTimestamp formats a Unix time for the RFC 3339 attributes like version_expires_at, "" for 0
+func formatTimestamp(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+// planExpiry plans a new generated version when the managed one expires within rotate_before,
+// and plans version_expires_at for the versions written by the plan and for changes of
+// expires_at
+func planExpiry(d *schema.ResourceDiff, now time.Time) error {
+	validFor := d.Get("valid_for").(string)
+
+	rotate := false
+	if rotateBefore := d.Get("rotate_before").(string); validFor != "" && rotateBefore != "" && d.Id() != "" &&
+		d.Get("version").(int) == 0 && len(d.Get("generate").([]interface{})) > 0 {
+		before, err := time.ParseDuration(rotateBefore)
+		if err != nil {
+			return fmt.Errorf("rotate_before: %w", err)
+		}
+		// Versions written before valid_for was set have no expiry and are never rotated
+		if expiry, err := time.Parse(time.RFC3339, d.Get("version_expires_at").(string)); err == nil {
+			rotate = !now.Add(before).Before(expiry)
+		}
+	}
+	if rotate {
+		// An unknown value makes Update generate a new one
+		if err := d.SetNewComputed("value"); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("latest_version"); err != nil {
+			return err
+		}
+	}
+
+	writes := d.Id() == "" || rotate || hasGenerateChange(d)
+	for _, key := range []string{"value", "source_file_sha256", "version", "latest_version"} {
+		writes = writes || d.HasChange(key)
+	}
+	switch {
+	case !writes && !d.HasChange("expires_at"):
+		return nil
+	case validFor != "":
+		return d.SetNewComputed("version_expires_at")
+	case d.Get("version_expires_at").(string) != d.Get("expires_at").(string):
+		// Only the configuration counts, versions written without expires_at do not expire
+		return d.SetNew("version_expires_at", d.Get("expires_at").(string))
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// expiryDiff plans a generated credstash_secret valid for 90 days and rotated a week before it
+// expires, against a state whose managed version expires at expiresAt
+func expiryDiff(t *testing.T, expiresAt time.Time) *terraform.InstanceDiff {
+	return secretExpiryDiff(t, expiresAt, map[string]interface{}{
+		"name":          "app",
+		"valid_for":     "2160h",
+		"rotate_before": "168h",
+		"generate":      []interface{}{map[string]interface{}{"length": 14, "use_symbols": false}},
+	})
+}
+
+// secretExpiryDiff plans config against the state of a generated credstash_secret valid for 90
+// days whose managed version expires at expiresAt
+func secretExpiryDiff(t *testing.T, expiresAt time.Time, raw map[string]interface{}) *terraform.InstanceDiff {
+	state := &terraform.InstanceState{ID: "credential-store/app", Attributes: map[string]string{
+		"id":                        "credential-store/app",
+		"name":                      "app",
+		"table":                     "",
+		"version":                   "0",
+		"drift_policy":              driftPolicyAdopt,
+		"expose_value_sha256":       "false",
+		"value":                     "hunter2hunter2",
+		"latest_version":            "1",
+		"entropy_bits":              "83.36",
+		"expires_at":                "",
+		"version_expires_at":        expiresAt.UTC().Format(time.RFC3339),
+		"valid_for":                 "2160h",
+		"rotate_before":             "168h",
+		"generate.#":                "1",
+		"generate.0.type":           generateTypePassword,
+		"generate.0.length":         "14",
+		"generate.0.use_symbols":    "false",
+		"generate.0.charsets.#":     "0",
+		"generate.0.min.%":          "0",
+		"generate.0.words":          "6",
+		"generate.0.separator":      "-",
+		"generate.0.wordlist":       "eff_large",
+		"generate.0.capitalize":     "false",
+		"generate.0.include_number": "false",
+		"generate.0.no_ambiguous":   "false",
+		"generate.0.custom_charset": "",
+		"generate.0.exclude_chars":  "",
+		"generate.0.seed":           "",
+		"generate.0.bytes":          "0",
+		"generate.0.template":       "",
+		"source_file_sha256":        "",
+		"metadata.%":                "0",
+		"value_sha256":              "",
+		"policy.#":                  "0",
+		"source_file":               "",
+		"context.%":                 "0",
+	}}
+	diff, err := resourceSecret().Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return diff
+}
+
+func TestExpiringSecretPlansRotation(t *testing.T) {
+	diff := expiryDiff(t, time.Now().Add(72*time.Hour))
+
+	for _, key := range []string{"value", "latest_version", "version_expires_at"} {
+		if diff == nil || diff.Attributes[key] == nil || !diff.Attributes[key].NewComputed {
+			t.Fatalf("expected %s to be planned as unknown, got %#v", key, diff)
+		}
+	}
+}
+
+func TestSecretFarFromExpiryIsNotRotated(t *testing.T) {
+	diff := expiryDiff(t, time.Now().Add(30*24*time.Hour))
+
+	if diff != nil && len(diff.Attributes) > 0 {
+		for key, attr := range diff.Attributes {
+			t.Errorf("expected no diff, got %s: %#v", key, attr)
+		}
+	}
+}
+
+func TestRemovingValidForStopsExpiringNewVersions(t *testing.T) {
+	// The managed version already expired, a version written without valid_for must not inherit it
+	diff := secretExpiryDiff(t, time.Now().Add(-time.Hour), map[string]interface{}{
+		"name":     "app",
+		"generate": []interface{}{map[string]interface{}{"length": 16, "use_symbols": false}},
+	})
+
+	if diff == nil || diff.Attributes["version_expires_at"] == nil || diff.Attributes["version_expires_at"].New != "" {
+		t.Fatalf("expected version_expires_at to be planned without an expiry, got %#v", diff)
+	}
+	if attr := diff.Attributes["expires_at"]; attr != nil {
+		t.Fatalf("expected expires_at to stay unset, got %#v", attr)
+	}
+
+	d := resourceSecret().TestResourceData()
+	d.Set("version_expires_at", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+	if expiresAt, err := secretExpiresAt(d, time.Now()); err != nil || expiresAt != 0 {
+		t.Fatalf("expected a new version without an expiry, got %d, %v", expiresAt, err)
+	}
+}
+
+func TestRemovingExpiresAtClearsExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour)
+	state := &terraform.InstanceState{ID: "credential-store/app", Attributes: map[string]string{
+		"id":                  "credential-store/app",
+		"name":                "app",
+		"table":               "",
+		"version":             "0",
+		"drift_policy":        driftPolicyAdopt,
+		"expose_value_sha256": "false",
+		"value":               "hunter2",
+		"latest_version":      "1",
+		"entropy_bits":        "0",
+		"expires_at":          expiresAt.UTC().Format(time.RFC3339),
+		"version_expires_at":  expiresAt.UTC().Format(time.RFC3339),
+		"generate.#":          "0",
+		"metadata.%":          "0",
+		"policy.#":            "0",
+		"context.%":           "0",
+	}}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":  "app",
+		"value": "hunter2",
+	})
+
+	diff, err := resourceSecret().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if diff == nil || diff.Attributes["expires_at"] == nil || !diff.Attributes["expires_at"].NewRemoved {
+		t.Fatalf("expected expires_at to be removed, got %#v", diff)
+	}
+	if attr := diff.Attributes["version_expires_at"]; attr == nil || attr.New != "" {
+		t.Fatalf("expected version_expires_at to be cleared, got %#v", attr)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	generateTypePassword   = "password"
+	generateTypePassphrase = "passphrase"
+	generateTypeUUID       = "uuid"
+	generateTypeHex        = "hex"
+	generateTypeBase64     = "base64"
+	generateTypeBase64URL  = "base64url"
+	generateTypeTemplate   = "template"
+)
+
+var generateTypes = []string{
+	generateTypePassword,
+	generateTypePassphrase,
+	generateTypeUUID,
+	generateTypeHex,
+	generateTypeBase64,
+	generateTypeBase64URL,
+	generateTypeTemplate,
+}
+
+// suppressNewDefault hides the diff of a generate setting added in a later release on secrets
+// created before it existed. Their state has no value for it while the configuration gets the
+// default, and the diff would otherwise regenerate the secret.
+func suppressNewDefault(def string) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		return d.Id() != "" && old == "" && new == def
+	}
+}
+
+// generateType returns the type of a generate block, states written before `type` existed
+// hold an empty string and generate passwords
+func generateType(settings map[string]interface{}) string {
+	if t, _ := settings["type"].(string); t != "" {
+		return t
+	}
+	return generateTypePassword
+}
+
+// validateGenerate checks the settings that only apply to some types, so mistakes fail the
+// plan instead of the apply
+func validateGenerate(settings map[string]interface{}) error {
+	switch generateType(settings) {
+	case generateTypePassword:
+		if settings["length"].(int) < 1 {
+			return fmt.Errorf("generate: `length` must be set to a positive number for type %q", generateTypePassword)
+		}
+		if _, err := credstash.PasswordEntropyBits(passwordOptions(settings)); err != nil {
+			return fmt.Errorf("generate: %w", err)
+		}
+	case generateTypePassphrase:
+		if settings["words"].(int) < 1 {
+			return fmt.Errorf("generate: `words` must be a positive number for type %q", generateTypePassphrase)
+		}
+	case generateTypeHex, generateTypeBase64, generateTypeBase64URL:
+		if settings["bytes"].(int) < 1 {
+			return fmt.Errorf("generate: `bytes` must be set to a positive number for type %q", generateType(settings))
+		}
+	case generateTypeTemplate:
+		if err := credstash.ParseTemplate(settings["template"].(string)); err != nil {
+			return fmt.Errorf("generate: %w", err)
+		}
+	}
+	return nil
+}
+
+// generateSecret generates a new value from the settings of a generate block. The value is
+// derived from the seed secret, the name and paddedVersion when `seed` is set.
+func generateSecret(client *credstash.Client, d *schema.ResourceData, settings map[string]interface{}, paddedVersion string) (string, error) {
+	if err := validateGenerate(settings); err != nil {
+		return "", err
+	}
+
+	var random io.Reader
+	if seed, _ := settings["seed"].(string); seed != "" {
+		var err error
+		random, err = client.SeedReaderFromSecret(d.Get("table").(string), seed,
+			encryptionContext(d.Get("context").(map[string]interface{})), d.Get("name").(string), paddedVersion)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch t := generateType(settings); t {
+	case generateTypePassword:
+		opts := passwordOptions(settings)
+		opts.Rand = random
+		return client.GeneratePassword(opts)
+	case generateTypePassphrase:
+		opts := passphraseOptions(settings)
+		opts.Rand = random
+		return client.GeneratePassphrase(opts)
+	case generateTypeUUID:
+		return client.GenerateUUID(random)
+	case generateTypeHex, generateTypeBase64, generateTypeBase64URL:
+		return client.GenerateBytes(settings["bytes"].(int), t, random)
+	case generateTypeTemplate:
+		return client.GenerateFromTemplate(settings["template"].(string), random)
+	default:
+		return "", fmt.Errorf("generate: unknown type %q", t)
+	}
+}
+
+// warnSeed makes every plan using `seed` show that the values it generates are predictable
+func warnSeed(v interface{}, path cty.Path) diag.Diagnostics {
+	if v.(string) == "" {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Deterministic secret generation is not for production",
+		Detail: fmt.Sprintf("The value is derived from the secret %q, the name and the version, so anyone able to read that secret "+
+			"can compute it. Only use `seed` for reproducible test fixtures.", v.(string)),
+		AttributePath: path,
+	}}
+}
+
+// generateEntropyBits returns the entropy of the secrets generated by the settings of a
+// generate block
+func generateEntropyBits(settings map[string]interface{}) (float64, error) {
+	switch t := generateType(settings); t {
+	case generateTypePassword:
+		return credstash.PasswordEntropyBits(passwordOptions(settings))
+	case generateTypePassphrase:
+		return credstash.PassphraseEntropyBits(passphraseOptions(settings))
+	case generateTypeUUID:
+		// 6 of the 128 bits hold the version and variant
+		return 122, nil
+	case generateTypeHex, generateTypeBase64, generateTypeBase64URL:
+		return float64(8 * settings["bytes"].(int)), nil
+	case generateTypeTemplate:
+		return credstash.TemplateEntropyBits(settings["template"].(string))
+	default:
+		return 0, fmt.Errorf("generate: unknown type %q", t)
+	}
+}
+
+// entropyBits returns the entropy of a generate block rounded to two decimals, 0 when there is
+// no block or its settings are invalid
+func entropyBits(generateList []interface{}) float64 {
+	if len(generateList) == 0 || generateList[0] == nil {
+		return 0
+	}
+	bits, err := generateEntropyBits(generateList[0].(map[string]interface{}))
+	if err != nil {
+		return 0
+	}
+	return math.Round(bits*100) / 100
+}
+
+func passwordOptions(settings map[string]interface{}) credstash.PasswordOptions {
+	opts := credstash.PasswordOptions{
+		Length:        settings["length"].(int),
+		UseSymbols:    settings["use_symbols"].(bool),
+		Min:           map[string]int{},
+		CustomCharset: settings["custom_charset"].(string),
+		ExcludeChars:  settings["exclude_chars"].(string),
+		NoAmbiguous:   settings["no_ambiguous"].(bool),
+	}
+	for _, name := range settings["charsets"].(*schema.Set).List() {
+		opts.Charsets = append(opts.Charsets, name.(string))
+	}
+	for name, min := range settings["min"].(map[string]interface{}) {
+		opts.Min[name] = min.(int)
+	}
+	return opts
+}
+
+func passphraseOptions(settings map[string]interface{}) credstash.PassphraseOptions {
+	return credstash.PassphraseOptions{
+		Words:         settings["words"].(int),
+		Separator:     settings["separator"].(string),
+		Wordlist:      settings["wordlist"].(string),
+		Capitalize:    settings["capitalize"].(bool),
+		IncludeNumber: settings["include_number"].(bool),
+	}
+}
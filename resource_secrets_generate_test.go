@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// generateDiff plans a credstash_secret with the given generate block against a state
+// holding the given generate attributes
+func generateDiff(t *testing.T, stateGenerate map[string]string, generate map[string]interface{}) *terraform.InstanceDiff {
+	r := resourceSecret()
+	// The drift check needs a client, the settings are validated by validateGenerate directly
+	r.CustomizeDiff = nil
+
+	attributes := map[string]string{
+		"id":                  "credential-store/app",
+		"name":                "app",
+		"table":               "",
+		"version":             "0",
+		"drift_policy":        driftPolicyAdopt,
+		"expose_value_sha256": "false",
+		"value":               "hunter2",
+		"value_sha256":        "",
+		"latest_version":      "1",
+		"generate.#":          "1",
+	}
+	for k, v := range stateGenerate {
+		attributes["generate.0."+k] = v
+	}
+	state := &terraform.InstanceState{ID: attributes["id"], Attributes: attributes}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":     "app",
+		"generate": []interface{}{generate},
+	})
+
+	diff, err := r.Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return diff
+}
+
+func TestGenerateNewSettingsDoNotDiffOnOldState(t *testing.T) {
+	// State written before the generate block had a type
+	diff := generateDiff(t, map[string]string{
+		"length":      "10",
+		"use_symbols": "true",
+		"charsets.#":  "0",
+		"min.%":       "0",
+	}, map[string]interface{}{"length": 10})
+
+	if diff != nil {
+		for k := range diff.Attributes {
+			if strings.HasPrefix(k, "generate.") {
+				t.Fatalf("expected no generate diff, got %s", k)
+			}
+		}
+	}
+}
+
+func TestGenerateTypeChangeDiffs(t *testing.T) {
+	diff := generateDiff(t, map[string]string{
+		"type":        generateTypePassword,
+		"length":      "10",
+		"use_symbols": "true",
+		"charsets.#":  "0",
+		"min.%":       "0",
+	}, map[string]interface{}{"type": generateTypePassphrase})
+
+	if diff == nil || diff.Attributes["generate.0.type"] == nil {
+		t.Fatalf("expected a diff on generate.0.type, got %#v", diff)
+	}
+}
+
+func TestValidateGenerate(t *testing.T) {
+	cases := []struct {
+		settings map[string]interface{}
+		valid    bool
+	}{
+		{settings: passwordSettings(map[string]interface{}{"length": 0}), valid: false},
+		{settings: passwordSettings(map[string]interface{}{"type": ""}), valid: true},
+		{settings: passwordSettings(map[string]interface{}{"custom_charset": "abc", "exclude_chars": "abc"}), valid: false},
+		{settings: passwordSettings(map[string]interface{}{"custom_charset": "abc", "min": map[string]interface{}{"numeric": 1}}), valid: false},
+		{settings: passwordSettings(map[string]interface{}{"exclude_chars": `"'\\`, "no_ambiguous": true}), valid: true},
+		{settings: map[string]interface{}{"type": generateTypePassphrase, "words": 0}, valid: false},
+		{settings: map[string]interface{}{"type": generateTypePassphrase, "words": 6}, valid: true},
+		{settings: map[string]interface{}{"type": generateTypeUUID}, valid: true},
+		{settings: map[string]interface{}{"type": generateTypeBase64URL, "bytes": 0}, valid: false},
+		{settings: map[string]interface{}{"type": generateTypeHex, "bytes": 32}, valid: true},
+		{settings: map[string]interface{}{"type": generateTypeTemplate, "template": "sk_live_{alnum:24}"}, valid: true},
+		{settings: map[string]interface{}{"type": generateTypeTemplate, "template": "sk_live_{alnum}"}, valid: false},
+	}
+	for _, tc := range cases {
+		err := validateGenerate(tc.settings)
+		if (err == nil) != tc.valid {
+			t.Fatalf("validateGenerate(%v) returned %v", tc.settings, err)
+		}
+	}
+}
+
+func TestEntropyBits(t *testing.T) {
+	cases := []struct {
+		generate []interface{}
+		bits     float64
+	}{
+		{generate: nil, bits: 0},
+		{generate: []interface{}{passwordSettings(map[string]interface{}{"length": 10, "use_symbols": false})}, bits: 59.54},
+		{generate: []interface{}{passwordSettings(map[string]interface{}{"length": 10, "custom_charset": "ab"})}, bits: 10},
+		{generate: []interface{}{map[string]interface{}{"type": generateTypeUUID}}, bits: 122},
+		{generate: []interface{}{map[string]interface{}{"type": generateTypeHex, "bytes": 32}}, bits: 256},
+	}
+	for _, tc := range cases {
+		if bits := entropyBits(tc.generate); bits != tc.bits {
+			t.Fatalf("entropyBits(%v) = %v, expected %v", tc.generate, bits, tc.bits)
+		}
+	}
+}
+
+// passwordSettings returns the settings of a password generate block as read from the schema,
+// with the given overrides
+func passwordSettings(overrides map[string]interface{}) map[string]interface{} {
+	settings := map[string]interface{}{
+		"type":           generateTypePassword,
+		"length":         12,
+		"use_symbols":    true,
+		"charsets":       schema.NewSet(schema.HashString, nil),
+		"min":            map[string]interface{}{},
+		"custom_charset": "",
+		"exclude_chars":  "",
+		"no_ambiguous":   false,
+	}
+	for k, v := range overrides {
+		settings[k] = v
+	}
+	return settings
+}
+
+func TestSeedWarnsAtPlanTime(t *testing.T) {
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":     "app",
+		"generate": []interface{}{map[string]interface{}{"length": 10, "seed": "fixtures.seed"}},
+	})
+
+	diags := resourceSecret().Validate(config)
+
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("expected a single warning, got %#v", diags)
+	}
+}
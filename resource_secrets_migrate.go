@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceSecretV0 is the credstash_secret schema used while the resource ID was
+// the SHA-256 of the secret value
+func resourceSecretV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name":    {Type: schema.TypeString, Required: true},
+			"table":   {Type: schema.TypeString, Optional: true, Default: ""},
+			"version": {Type: schema.TypeInt, Optional: true, Default: 0},
+			"context": {Type: schema.TypeMap, Optional: true},
+			"value":   {Type: schema.TypeString, Optional: true, Computed: true, Sensitive: true},
+			"generate": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"length":      {Type: schema.TypeInt, Required: true},
+						"use_symbols": {Type: schema.TypeBool, Optional: true, Default: true},
+						"charsets":    {Type: schema.TypeSet, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"min":         {Type: schema.TypeMap, Optional: true, Elem: &schema.Schema{Type: schema.TypeInt}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourceSecretStateUpgradeV0 replaces the value derived ID with table/name
+func resourceSecretStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, m interface{}) (map[string]interface{}, error) {
+	name, _ := rawState["name"].(string)
+	table, _ := rawState["table"].(string)
+	if client, ok := m.(*credstash.Client); ok {
+		table = client.TableName(table)
+	}
+
+	rawState["id"] = secretID(table, name)
+	tflog.Debug(ctx, "resourceSecretStateUpgradeV0 upgraded ID", map[string]interface{}{
+		"id": rawState["id"],
+	})
+	return rawState, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+)
+
+// secretPolicy converts a policy block, returning nil when there is none
+func secretPolicy(policyList []interface{}) *credstash.Policy {
+	if len(policyList) == 0 || policyList[0] == nil {
+		return nil
+	}
+	settings := policyList[0].(map[string]interface{})
+
+	require := map[string]int{}
+	for name, n := range settings["require"].(map[string]interface{}) {
+		require[name] = n.(int)
+	}
+	return &credstash.Policy{
+		MinLength:      settings["min_length"].(int),
+		MaxLength:      settings["max_length"].(int),
+		Require:        require,
+		ForbidChars:    settings["forbid_chars"].(string),
+		MinEntropyBits: settings["min_entropy_bits"].(float64),
+	}
+}
+
+// checkPolicy checks a supplied value against the policy block. Generated values are not
+// checked, the generate settings decide their shape.
+func checkPolicy(policyList []interface{}, value string) error {
+	policy := secretPolicy(policyList)
+	if policy == nil {
+		return nil
+	}
+	if err := policy.Check(value); err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+	return nil
+}
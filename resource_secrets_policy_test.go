@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	policy := []interface{}{map[string]interface{}{
+		"min_length":       12,
+		"max_length":       0,
+		"require":          map[string]interface{}{"uppercase": 1},
+		"forbid_chars":     `"`,
+		"min_entropy_bits": 0.0,
+	}}
+
+	if err := checkPolicy(policy, "Tr0ub4dor&3xyz"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	err := checkPolicy(policy, `hunter"2`)
+	if err == nil {
+		t.Fatal("expected a policy error")
+	}
+	if strings.Contains(err.Error(), "hunter") {
+		t.Fatalf("policy error shows the value: %s", err)
+	}
+	if err := checkPolicy(nil, "x"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
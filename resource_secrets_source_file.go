@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// planSourceFile reads source_file at plan time and plans its hash, so changing the file plans
+// a new version without the contents ever reaching the plan or the state
+func planSourceFile(d *schema.ResourceDiff) error {
+	if !d.NewValueKnown("source_file") {
+		return d.SetNewComputed("source_file_sha256")
+	}
+
+	sum := ""
+	if path := d.Get("source_file").(string); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("source_file: %w", err)
+		}
+		sum = hash(string(content))
+	}
+	if sum != d.Get("source_file_sha256").(string) {
+		return d.SetNew("source_file_sha256", sum)
+	}
+	return nil
+}
+
+// readSourceFile reads source_file at apply time and fails when it no longer has the planned
+// hash. The hash of the contents read is always stored, it was unknown at plan time when the
+// path was.
+func readSourceFile(d *schema.ResourceData) (string, error) {
+	path := d.Get("source_file").(string)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("source_file: %w", err)
+	}
+	sum := hash(string(content))
+	if planned := d.Get("source_file_sha256").(string); planned != "" && planned != sum {
+		return "", fmt.Errorf("source_file %q changed since the plan, run the plan again", path)
+	}
+	d.Set("source_file_sha256", sum)
+	return string(content), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// sourceFileDiff plans a credstash_secret reading path against a state holding stateSHA256,
+// or against no state when stateSHA256 is empty
+func sourceFileDiff(t *testing.T, path string, stateSHA256 string) *terraform.InstanceDiff {
+	var state *terraform.InstanceState
+	if stateSHA256 != "" {
+		state = &terraform.InstanceState{ID: "credential-store/cert", Attributes: map[string]string{
+			"id":                  "credential-store/cert",
+			"name":                "cert",
+			"table":               "",
+			"version":             "0",
+			"drift_policy":        driftPolicyAdopt,
+			"expose_value_sha256": "false",
+			"value":               "",
+			"latest_version":      "1",
+			"source_file":         path,
+			"source_file_sha256":  stateSHA256,
+			"entropy_bits":        "0",
+		}}
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":        "cert",
+		"source_file": path,
+	})
+
+	diff, err := resourceSecret().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return diff
+}
+
+func TestSourceFilePlansItsHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := ioutil.WriteFile(path, []byte("-----BEGIN CERTIFICATE-----"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sum := hash("-----BEGIN CERTIFICATE-----")
+
+	diff := sourceFileDiff(t, path, "")
+	if diff == nil || diff.Attributes["source_file_sha256"] == nil || diff.Attributes["source_file_sha256"].New != sum {
+		t.Fatalf("expected source_file_sha256 to be planned as %s, got %#v", sum, diff)
+	}
+
+	if diff := sourceFileDiff(t, path, sum); diff != nil && diff.Attributes["source_file_sha256"] != nil {
+		t.Fatalf("expected no diff for an unchanged file, got %#v", diff.Attributes["source_file_sha256"])
+	}
+
+	diff = sourceFileDiff(t, path, hash("old certificate"))
+	if diff == nil || diff.Attributes["source_file_sha256"] == nil || diff.Attributes["source_file_sha256"].New != sum {
+		t.Fatalf("expected a changed file to plan a new hash, got %#v", diff)
+	}
+}
+
+func TestSourceFileMissing(t *testing.T) {
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":        "cert",
+		"source_file": filepath.Join(t.TempDir(), "missing.pem"),
+	})
+
+	if _, err := resourceSecret().Diff(context.Background(), nil, config, nil); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func TestReadSourceFileStoresItsHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := ioutil.WriteFile(path, []byte("-----BEGIN CERTIFICATE-----"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The path was unknown at plan time, so no hash was planned
+	d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{
+		"name":        "cert",
+		"source_file": path,
+	})
+
+	if _, err := readSourceFile(d); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if sum := d.Get("source_file_sha256").(string); sum != hash("-----BEGIN CERTIFICATE-----") {
+		t.Fatalf("expected the hash of the file to be stored, got %q", sum)
+	}
+
+	d.Set("source_file_sha256", hash("planned certificate"))
+	if _, err := readSourceFile(d); err == nil {
+		t.Fatal("expected an error for a file changed since the plan")
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/granular-oss/terraform-provider-credstash/credstash"
+	"github.com/granular-oss/terraform-provider-credstash/credstash/credstashtest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// newTestClient returns a client of the credential-store table backed by in-memory fakes
+func newTestClient(opts credstash.Options) (*credstash.Client, *credstashtest.DynamoDB) {
+	db := credstashtest.NewDynamoDB()
+	return credstash.NewWithOptions("credential-store", credstashtest.Session(db, &credstashtest.KMS{}), opts), db
+}
+
+func TestResourceSecretStateUpgradeV0(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")}))
+	client := credstash.New("credential-store", sess)
+
+	cases := []struct {
+		table string
+		want  string
+	}{
+		{table: "", want: "credential-store/app/db_password"},
+		{table: "other-table", want: "other-table/app/db_password"},
+	}
+	for _, tc := range cases {
+		rawState := map[string]interface{}{
+			"id":    hash("hunter2"),
+			"name":  "app/db_password",
+			"table": tc.table,
+			"value": "hunter2",
+		}
+
+		upgraded, err := resourceSecretStateUpgradeV0(context.Background(), rawState, client)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if upgraded["id"] != tc.want {
+			t.Fatalf("expected id %q, got %q", tc.want, upgraded["id"])
+		}
+	}
+}
+
+func TestResourceSecretV0MatchesReleasedSchema(t *testing.T) {
+	// The attributes of the last release, before table/name IDs
+	released := []string{"context", "generate", "name", "table", "value", "version"}
+
+	v0 := resourceSecretV0().Schema
+	keys := make([]string, 0, len(v0))
+	for k := range v0 {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if !reflect.DeepEqual(keys, released) {
+		t.Fatalf("expected the v0 schema to hold %v, got %v", released, keys)
+	}
+}
+
+func TestHasGenerateChange(t *testing.T) {
+	cases := []struct {
+		diff    map[string]*terraform.ResourceAttrDiff
+		changed bool
+	}{
+		// A restore only changes latest_version, which must not regenerate the secret
+		{diff: map[string]*terraform.ResourceAttrDiff{"latest_version": {Old: "1", NewComputed: true}}, changed: false},
+		{diff: map[string]*terraform.ResourceAttrDiff{"generate.0.length": {Old: "10", New: "12"}}, changed: true},
+		{diff: map[string]*terraform.ResourceAttrDiff{
+			"generate.0.charsets.#": {Old: "1", New: "1"},
+			"generate.0.charsets.0": {Old: "numeric", New: "letters"},
+		}, changed: true},
+	}
+	for _, tc := range cases {
+		instance := &terraform.InstanceState{ID: "credential-store/app", Attributes: map[string]string{
+			"id":                     "credential-store/app",
+			"name":                   "app",
+			"latest_version":         "1",
+			"generate.#":             "1",
+			"generate.0.length":      "10",
+			"generate.0.use_symbols": "true",
+			"generate.0.charsets.#":  "1",
+			"generate.0.charsets.0":  "numeric",
+			"generate.0.min.%":       "0",
+		}}
+		d, err := schema.InternalMap(resourceSecret().Schema).Data(instance, &terraform.InstanceDiff{Attributes: tc.diff})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed := hasGenerateChange(d); changed != tc.changed {
+			t.Fatalf("hasGenerateChange with %v = %v, expected %v", tc.diff, changed, tc.changed)
+		}
+	}
+}
+
+func TestProtectedPrefixes(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")}))
+	client := credstash.NewWithOptions("credential-store", sess, credstash.Options{ProtectedPrefixes: []string{"prod/"}})
+
+	cases := []struct {
+		config    map[string]interface{}
+		protected string
+	}{
+		{config: map[string]interface{}{"name": "prod/db", "value": "hunter2"}, protected: "true"},
+		{config: map[string]interface{}{"name": "prod/db", "value": "hunter2", "protected": false}, protected: "false"},
+		{config: map[string]interface{}{"name": "dev/db", "value": "hunter2"}, protected: ""},
+		{config: map[string]interface{}{"name": "dev/db", "value": "hunter2", "protected": true}, protected: "true"},
+	}
+	for _, tc := range cases {
+		diff, err := resourceSecret().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(tc.config), client)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		protected := ""
+		if attr := diff.Attributes["protected"]; attr != nil && !attr.NewComputed {
+			protected = attr.New
+		}
+		if protected != tc.protected {
+			t.Fatalf("planned protected = %q with %v, expected %q", protected, tc.config, tc.protected)
+		}
+	}
+}
+
+func TestDeleteProtectedSecret(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{
+		"name":      "prod/db",
+		"value":     "hunter2",
+		"protected": true,
+	})
+
+	// No client is needed, the delete must fail before reaching DynamoDB
+	diags := resourceSecretDelete(context.Background(), d, nil)
+
+	if !diags.HasError() || diags[0].Summary != "Secret is protected" {
+		t.Fatalf("expected a protected error, got %#v", diags)
+	}
+}
+
+func TestReadSecretDeletedOutsideTerraform(t *testing.T) {
+	client, _ := newTestClient(credstash.Options{})
+	d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{
+		"name":  "app",
+		"value": "hunter2",
+	})
+	d.SetId("credential-store/app")
+
+	diags := resourceSecretRead(context.Background(), d, client)
+
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning || diags[0].Summary != "Secret deleted outside of Terraform" {
+		t.Fatalf("expected a deleted outside of Terraform warning, got %#v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected the secret to be removed from state, got ID %q", d.Id())
+	}
+
+	// A secret missing right after it was created is an error, not a drift
+	d = schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{
+		"name":  "app",
+		"value": "hunter2",
+	})
+	d.SetId("credential-store/app")
+	d.MarkNewResource()
+
+	diags = resourceSecretRead(context.Background(), d, client)
+
+	if !diags.HasError() || diags[0].Summary != "Secret not found" {
+		t.Fatalf("expected a not found error, got %#v", diags)
+	}
+	if d.Id() == "" {
+		t.Fatal("expected a new resource to keep its ID")
+	}
+}
+
+func TestReadSecretDriftPolicies(t *testing.T) {
+	cases := []struct {
+		policy        string
+		value         string
+		latestVersion int
+		summary       string
+		severity      diag.Severity
+	}{
+		{policy: driftPolicyAdopt, value: "outside", latestVersion: 2, summary: "Secret changed outside of Terraform", severity: diag.Warning},
+		{policy: driftPolicyRestore, value: "managed", latestVersion: 1, summary: "Secret changed outside of Terraform", severity: diag.Warning},
+		{policy: driftPolicyError, summary: "Secret changed outside of Terraform", severity: diag.Error},
+	}
+	for _, tc := range cases {
+		client, _ := newTestClient(credstash.Options{})
+		ctx := credstash.NewEncryptionContextValue()
+		if err := client.PutSecret("", "app", "managed", client.PaddedInt(1), ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.PutSecret("", "app", "outside", client.PaddedInt(2), ctx); err != nil {
+			t.Fatal(err)
+		}
+		d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{
+			"name":         "app",
+			"value":        "managed",
+			"drift_policy": tc.policy,
+		})
+		d.SetId("credential-store/app")
+		d.Set("latest_version", 1)
+
+		diags := resourceSecretRead(context.Background(), d, client)
+
+		if len(diags) != 1 || diags[0].Severity != tc.severity || diags[0].Summary != tc.summary {
+			t.Fatalf("drift_policy %s: unexpected diagnostics %#v", tc.policy, diags)
+		}
+		if tc.severity == diag.Error {
+			continue
+		}
+		if value := d.Get("value").(string); value != tc.value {
+			t.Fatalf("drift_policy %s: value = %q, expected %q", tc.policy, value, tc.value)
+		}
+		if latest := d.Get("latest_version").(int); latest != tc.latestVersion {
+			t.Fatalf("drift_policy %s: latest_version = %d, expected %d", tc.policy, latest, tc.latestVersion)
+		}
+	}
+}
+
+func TestImportSecretIDs(t *testing.T) {
+	client, _ := newTestClient(credstash.Options{})
+	ctx := credstash.NewEncryptionContextValue()
+	for _, name := range []string{"app/db_password", "svc:token"} {
+		if err := client.PutSecret("", name, "hunter2", client.PaddedInt(1), ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		id    string
+		table string
+		name  string
+	}{
+		{id: "app/db_password", table: "", name: "app/db_password"},
+		{id: "svc:token", table: "", name: "svc:token"},
+		{id: "other-table:app/db_password", table: "other-table", name: "app/db_password"},
+		{id: "credential-store:app/db_password", table: "", name: "app/db_password"},
+	}
+	for _, tc := range cases {
+		d := resourceSecret().TestResourceData()
+		d.SetId(tc.id)
+		if _, err := resourceSecretStateImporter(context.Background(), d, client); err != nil {
+			t.Fatalf("import %q: %s", tc.id, err)
+		}
+		if d.Get("table").(string) != tc.table || d.Get("name").(string) != tc.name {
+			t.Fatalf("import %q read table %q and name %q, expected %q and %q",
+				tc.id, d.Get("table"), d.Get("name"), tc.table, tc.name)
+		}
+	}
+}